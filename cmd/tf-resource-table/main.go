@@ -0,0 +1,297 @@
+// Command tf-resource-table generates Markdown tables documenting the resources
+// of a Terraform module, outside of GitHub Actions.
+//
+// Usage:
+//
+//	tf-resource-table generate [flags]
+//	tf-resource-table check [flags]
+//	tf-resource-table init-config [flags]
+//
+// Every flag may also be set with an environment variable, prefixed with
+// TF_RESOURCE_TABLE_, e.g. TF_RESOURCE_TABLE_OUTPUT_FILE for -output-file.
+// Flags take precedence over environment variables.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/action"
+)
+
+const envPrefix = "TF_RESOURCE_TABLE_"
+
+const usage = `Usage: tf-resource-table <command> [flags]
+
+Commands:
+  generate     write the tables to the output file
+  check        fail if the output file is out of date
+  init-config  print a starter configuration file for the module
+
+Run tf-resource-table <command> -h for the flags of a command.
+`
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.LookupEnv, os.Stdout, os.Stderr))
+}
+
+// run runs the command line and returns the exit code.
+func run(ctx context.Context, args []string, lookupEnv func(string) (string, bool), stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "generate", "check":
+		inputs, opts, err := parseInputs(command, args, lookupEnv, stderr)
+		if err != nil {
+			return usageError(err, stderr)
+		}
+
+		r := &reporter{out: stderr, stdout: stdout, debug: opts.debug}
+
+		if opts.summaryFile != "" {
+			f, err := os.OpenFile(opts.summaryFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			if err != nil {
+				fmt.Fprintf(stderr, "error: failed to open summary file: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+
+			r.summary = f
+		}
+
+		if opts.outputsFile != "" {
+			f, err := os.OpenFile(opts.outputsFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+			if err != nil {
+				fmt.Fprintf(stderr, "error: failed to open outputs file: %v\n", err)
+				return 1
+			}
+			defer f.Close()
+
+			r.outputs = f
+		}
+
+		if err := action.Run(ctx, inputs, r); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+
+		return 0
+	case "init-config":
+		fs := flag.NewFlagSet(command, flag.ContinueOnError)
+		fs.SetOutput(stderr)
+		dir := fs.String("working-directory", ".", "the directory containing the Terraform module")
+
+		if err := parseFlags(fs, args, lookupEnv); err != nil {
+			return usageError(err, stderr)
+		}
+
+		config, err := action.StarterConfig(*dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+
+		stdout.Write(config)
+
+		return 0
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", command, usage)
+		return 2
+	}
+}
+
+func usageError(err error, stderr io.Writer) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "error: %v\n", err)
+
+	return 2
+}
+
+// options are the settings of the command line which are not action inputs.
+type options struct {
+	debug bool
+	// summaryFile is the file summaries are appended to, e.g. $GITHUB_STEP_SUMMARY.
+	summaryFile string
+	// outputsFile is the file outputs are appended to, e.g. $GITHUB_OUTPUT.
+	outputsFile string
+}
+
+// parseInputs parses the flags and environment variables of the generate and check commands.
+func parseInputs(command string, args []string, lookupEnv func(string) (string, bool), stderr io.Writer) (action.Inputs, options, error) {
+	inputs := action.Inputs{Mode: action.ModeWrite}
+	if command == "check" {
+		inputs.Mode = action.ModeCheck
+	}
+
+	var resources, resourcesFile string
+	var requireFence bool
+	var opts options
+
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&inputs.WorkingDirectory, "working-directory", ".", "the directory containing the Terraform module, or newline-separated directories and glob patterns")
+	fs.IntVar(&inputs.Concurrency, "concurrency", 0, fmt.Sprintf("the maximum number of modules documented concurrently (default %d)", action.DefaultConcurrency))
+	fs.StringVar(&inputs.InventoryFile, "inventory-file", "", "the file listing the resources of all modules, relative to the current directory")
+	fs.StringVar(&inputs.Include, "include", "", "newline-separated glob patterns of the module files to load, e.g. *.tf")
+	fs.StringVar(&inputs.Exclude, "exclude", "", "newline-separated glob patterns of the module files and directories to ignore, e.g. *_test.tf")
+	fs.StringVar(&inputs.JSONFile, "json-file", "", "the file the resources of all modules are written to as JSON, relative to the current directory")
+	fs.StringVar(&inputs.CompareRef, "compare-ref", "", "the git ref to report the changes of the documented resources since, e.g. origin/main")
+	fs.StringVar(&inputs.OutputFile, "output-file", "", "the file the tables are written to, relative to the working directory, or - or empty to print them (default README.md)")
+	fs.StringVar(&resources, "resources", "", "a YAML-encoded list of resource types")
+	fs.StringVar(&resourcesFile, "resources-file", "", "a file containing a YAML-encoded list of resource types, overriding "+action.ConfigFileName)
+	fs.IntVar(&inputs.HeaderLevel, "header-level", 0, "the header level of each table (default 2)")
+	fs.BoolVar(&inputs.DryRun, "dry-run", false, "print a diff of the changes to the output file instead of writing them")
+	fs.BoolVar(&requireFence, "require-fence", false, "fail if the fences of a section are not found, instead of appending them")
+	fs.StringVar(&inputs.FenceStyle, "fence-style", "", "the comment style of the fences: html (default), mdx or rst")
+	fs.StringVar(&inputs.FenceBegin, "fence-begin", "", "the keyword of the comments beginning a section")
+	fs.StringVar(&inputs.FenceEnd, "fence-end", "", "the keyword of the comments ending a section")
+	fs.StringVar(&inputs.AnchorHeading, "anchor-heading", "", "write the tables below this heading instead of between fences")
+	fs.StringVar(&inputs.LinkMode, "link-mode", "", "how resources link to their source: relative (default) or permalink")
+	fs.StringVar(&inputs.LinkRef, "link-ref", "", "the ref permalinks point at: sha (default), branch or tag")
+	fs.StringVar(&opts.summaryFile, "summary-file", "", "append the tables of each module to this file, e.g. $GITHUB_STEP_SUMMARY")
+	fs.BoolVar(&inputs.SummaryCollapsed, "summary-collapsed", false, "collapse the tables of each module in the summary file")
+	fs.StringVar(&opts.outputsFile, "outputs-file", "", "append the outputs, e.g. changed and json, to this file in the format of $GITHUB_OUTPUT")
+	fs.BoolVar(&opts.debug, "debug", false, "log debug messages")
+
+	if err := parseFlags(fs, args, lookupEnv); err != nil {
+		return action.Inputs{}, options{}, err
+	}
+
+	if resources != "" && resourcesFile != "" {
+		return action.Inputs{}, options{}, errors.New("only one of -resources and -resources-file may be set")
+	}
+
+	if resourcesFile != "" {
+		b, err := os.ReadFile(resourcesFile)
+		if err != nil {
+			return action.Inputs{}, options{}, fmt.Errorf("failed to read resources file: %w", err)
+		}
+
+		resources = string(b)
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "output-file":
+			// an explicitly empty output file prints the tables, as does -
+			if inputs.OutputFile == "" {
+				inputs.OutputFile = action.NoOutputFile
+			}
+		case "require-fence":
+			// if set, it overrides the configuration files, even if false
+			inputs.RequireFence = &requireFence
+		}
+	})
+
+	inputs.ResourceTypes = action.ResourcesInput(resources)
+	inputs.Summary = opts.summaryFile != ""
+
+	return inputs, opts, nil
+}
+
+// parseFlags sets flags from their environment variables, then parses the arguments.
+func parseFlags(fs *flag.FlagSet, args []string, lookupEnv func(string) (string, bool)) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if value, ok := lookupEnv(name); ok && err == nil {
+			if setErr := fs.Set(f.Name, value); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %w", value, name, setErr)
+			}
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	return nil
+}
+
+// reporter logs to stderr, prints the tables of modules without an output
+// file to stdout, and appends outputs and summaries to the outputs and summary
+// files, if any.
+type reporter struct {
+	out     io.Writer
+	stdout  io.Writer
+	debug   bool
+	outputs io.Writer
+	summary io.Writer
+}
+
+func (r *reporter) Debugf(msg string, args ...interface{}) {
+	if r.debug {
+		fmt.Fprintf(r.out, "debug: "+msg+"\n", args...)
+	}
+}
+
+func (r *reporter) Infof(msg string, args ...interface{}) {
+	fmt.Fprintf(r.out, msg+"\n", args...)
+}
+
+func (r *reporter) SetOutput(name string, value string) {
+	if r.outputs == nil {
+		return
+	}
+
+	delimiter, err := outputDelimiter()
+	if err != nil {
+		fmt.Fprintf(r.out, "failed to write output %s: %v\n", name, err)
+		return
+	}
+
+	if _, err := fmt.Fprintf(r.outputs, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter); err != nil {
+		fmt.Fprintf(r.out, "failed to write output %s: %v\n", name, err)
+	}
+}
+
+func (r *reporter) PrintMarkdown(markdown string) {
+	fmt.Fprint(r.stdout, markdown)
+}
+
+func (r *reporter) AddStepSummary(markdown string) {
+	if r.summary == nil {
+		return
+	}
+
+	if _, err := io.WriteString(r.summary, markdown+"\n"); err != nil {
+		fmt.Fprintf(r.out, "failed to write summary: %v\n", err)
+	}
+}
+
+// outputDelimiter returns a random delimiter of multi-line output values,
+// which cannot occur in the value.
+func outputDelimiter() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return "EOF_" + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/action"
+)
+
+func TestParseInputs(t *testing.T) {
+	t.Parallel()
+
+	defaults := action.Inputs{
+		WorkingDirectory: ".",
+		Mode:             action.ModeWrite,
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		env     map[string]string
+		want    func(action.Inputs) action.Inputs
+		wantErr bool
+	}{
+		{
+			name:    "defaults",
+			command: "generate",
+			want:    func(i action.Inputs) action.Inputs { return i },
+		},
+		{
+			name:    "check",
+			command: "check",
+			want: func(i action.Inputs) action.Inputs {
+				i.Mode = action.ModeCheck
+				return i
+			},
+		},
+		{
+			name:    "flags",
+			command: "generate",
+			args:    []string{"-working-directory", "modules/foo", "-output-file=-", "-header-level", "3", "-resources", "[{name: foo}]"},
+			want: func(i action.Inputs) action.Inputs {
+				i.WorkingDirectory = "modules/foo"
+				i.OutputFile = action.NoOutputFile
+				i.HeaderLevel = 3
+				i.ResourceTypes = "[{name: foo}]"
+				return i
+			},
+		},
+		{
+			name:    "empty output file",
+			command: "generate",
+			args:    []string{"-output-file="},
+			want: func(i action.Inputs) action.Inputs {
+				i.OutputFile = action.NoOutputFile
+				return i
+			},
+		},
+		{
+			name:    "require fence off",
+			command: "generate",
+			args:    []string{"-require-fence=false"},
+			want: func(i action.Inputs) action.Inputs {
+				requireFence := false
+				i.RequireFence = &requireFence
+				return i
+			},
+		},
+		{
+			name:    "environment",
+			command: "generate",
+			args:    []string{"-link-mode", "relative"},
+			env: map[string]string{
+				"TF_RESOURCE_TABLE_DRY_RUN":   "true",
+				"TF_RESOURCE_TABLE_LINK_MODE": "permalink",
+				"TF_RESOURCE_TABLE_LINK_REF":  "branch",
+			},
+			want: func(i action.Inputs) action.Inputs {
+				i.DryRun = true
+				i.LinkMode = action.LinkModeRelative
+				i.LinkRef = action.LinkRefBranch
+				return i
+			},
+		},
+		{
+			name:    "summary",
+			command: "generate",
+			args:    []string{"-summary-file", "summary.md", "-summary-collapsed"},
+			want: func(i action.Inputs) action.Inputs {
+				i.Summary = true
+				i.SummaryCollapsed = true
+				return i
+			},
+		},
+		{
+			name:    "invalid environment",
+			command: "generate",
+			env:     map[string]string{"TF_RESOURCE_TABLE_HEADER_LEVEL": "two"},
+			wantErr: true,
+		},
+		{
+			name:    "unexpected argument",
+			command: "generate",
+			args:    []string{"README.md"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			lookupEnv := func(name string) (string, bool) {
+				value, ok := tc.env[name]
+				return value, ok
+			}
+
+			got, _, err := parseInputs(tc.command, tc.args, lookupEnv, io.Discard)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseInputs() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(tc.want(defaults), got); diff != "" {
+				t.Errorf("unexpected inputs -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestReporter_SetOutput(t *testing.T) {
+	t.Parallel()
+
+	var outputs strings.Builder
+	r := &reporter{out: io.Discard, outputs: &outputs}
+
+	r.SetOutput("changed", "true")
+	r.SetOutput("markdown", "a\nb")
+
+	want := regexp.MustCompile("^changed<<(EOF_[0-9a-f]{32})\ntrue\n(EOF_[0-9a-f]{32})\nmarkdown<<(EOF_[0-9a-f]{32})\na\nb\n(EOF_[0-9a-f]{32})\n$")
+
+	m := want.FindStringSubmatch(outputs.String())
+	if m == nil || m[1] != m[2] || m[3] != m[4] {
+		t.Errorf("unexpected outputs %q", outputs.String())
+	}
+
+	// without an outputs file, outputs are dropped
+	(&reporter{out: io.Discard}).SetOutput("changed", "true")
+}
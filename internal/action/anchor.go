@@ -0,0 +1,124 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrHeadingNotFound is returned if the anchor heading is required but not found.
+var ErrHeadingNotFound = errors.New("heading not found")
+
+// headingLevel returns the level of an ATX heading line, e.g. 2 for `## Resources`,
+// or 0 if the line is not a heading.
+func headingLevel(line string) int {
+	line = strings.TrimRight(line, "\r\n")
+
+	level := len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 || (len(line) > level && line[level] != ' ' && line[level] != '\t') {
+		return 0
+	}
+
+	return level
+}
+
+// ValidateAnchorHeading returns an error if heading is not a Markdown heading, e.g. `## Resources`.
+func ValidateAnchorHeading(heading string) error {
+	if headingLevel(heading) == 0 || strings.Contains(heading, "\n") {
+		return fmt.Errorf("anchor heading %q must be a single-line heading starting with #", heading)
+	}
+
+	if headingLevel(heading) == 6 {
+		return fmt.Errorf("anchor heading %q leaves no heading level for the tables", heading)
+	}
+
+	return nil
+}
+
+// nestHeaderLevels raises the header levels of the tables below the level of
+// the anchor heading, so that the replaced content ends at the next heading
+// which is not part of the tables. It returns the raised default header level.
+func nestHeaderLevels(heading string, headerLevel int, resourceTypes TerraformResources) int {
+	level := headingLevel(heading)
+	if headerLevel <= level {
+		headerLevel = level + 1
+	}
+
+	for _, resourceType := range resourceTypes {
+		if resourceType.HeaderLevel != 0 && resourceType.HeaderLevel <= level {
+			resourceType.HeaderLevel = level + 1
+		}
+	}
+
+	return headerLevel
+}
+
+// updateUnderHeading returns the existing content with the content below the
+// heading replaced, up to the next heading of the same or a higher level.
+// If the heading is not found, it is appended, unless requireHeading is set.
+func updateUnderHeading(existing []byte, heading string, content []byte, requireHeading bool) ([]byte, error) {
+	level := headingLevel(heading)
+	heading = strings.TrimSpace(heading)
+
+	start, end := -1, len(existing)
+	code := &codeBlocks{}
+
+	offset := 0
+	for _, line := range bytes.SplitAfter(existing, []byte("\n")) {
+		lineStart := offset
+		offset += len(line)
+
+		if code.skip(string(line)) {
+			continue
+		}
+
+		trimmed := strings.TrimSpace(string(line))
+
+		if start == -1 {
+			if trimmed == heading {
+				start = offset
+			}
+
+			continue
+		}
+
+		if l := headingLevel(string(line)); l != 0 && l <= level {
+			end = lineStart
+			break
+		}
+	}
+
+	var b bytes.Buffer
+
+	if start == -1 {
+		if requireHeading {
+			return nil, fmt.Errorf("%w: %s", ErrHeadingNotFound, heading)
+		}
+
+		if err := writeBytes(&b, withTrailingNewline(existing), []byte(heading), []byte("\n\n"), content); err != nil {
+			return nil, err
+		}
+
+		return b.Bytes(), nil
+	}
+
+	var separator []byte
+	if end < len(existing) {
+		separator = []byte("\n")
+	}
+
+	if err := writeBytes(
+		&b,
+		existing[:start],
+		newlineIfMissing(existing[:start]),
+		[]byte("\n"),
+		content,
+		separator,
+		existing[end:],
+	); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
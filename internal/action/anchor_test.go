@@ -0,0 +1,115 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdateUnderHeading(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		existing       string
+		heading        string
+		requireHeading bool
+		want           string
+		wantErr        error
+	}{
+		{
+			name:     "append",
+			existing: "# Module\n",
+			heading:  "## Resources",
+			want:     "# Module\n\n## Resources\n\ntables\n",
+		},
+		{
+			name:     "replace until next heading",
+			existing: "# Module\n\n## Resources\n\nold\n\n### Monitors\n\nold\n\n## Inputs\n\ninputs\n",
+			heading:  "## Resources",
+			want:     "# Module\n\n## Resources\n\ntables\n\n## Inputs\n\ninputs\n",
+		},
+		{
+			name:     "replace until end",
+			existing: "# Module\n\n## Resources\n\nold\n",
+			heading:  "## Resources",
+			want:     "# Module\n\n## Resources\n\ntables\n",
+		},
+		{
+			name:     "higher level heading",
+			existing: "## Resources\nold\n# Appendix\n",
+			heading:  "## Resources",
+			want:     "## Resources\n\ntables\n\n# Appendix\n",
+		},
+		{
+			name:     "headings in code blocks",
+			existing: "## Resources\n```sh\n## not a heading\n```\n## Inputs\n",
+			heading:  "## Resources",
+			want:     "## Resources\n\ntables\n\n## Inputs\n",
+		},
+		{
+			name:           "require heading",
+			existing:       "# Module\n",
+			heading:        "## Resources",
+			requireHeading: true,
+			wantErr:        ErrHeadingNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := updateUnderHeading([]byte(tc.existing), tc.heading, []byte("tables\n"), tc.requireHeading)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("updateUnderHeading() error = %v, want %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("unexpected content -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUpdateUnderHeading_Idempotent(t *testing.T) {
+	t.Parallel()
+
+	resourceTypes := TerraformResources{{Name: "observe_monitor"}, {Name: "observe_dataset", HeaderLevel: 1}}
+	headerLevel := nestHeaderLevels("## Resources", DefaultHeaderLevel, resourceTypes)
+
+	if headerLevel != 3 || resourceTypes[1].HeaderLevel != 3 {
+		t.Fatalf("header levels = %d, %d, want 3, 3", headerLevel, resourceTypes[1].HeaderLevel)
+	}
+
+	var content bytes.Buffer
+	for _, resourceType := range resourceTypes {
+		if err := WriteMarkdown(*resourceType, nil, MarkdownOptions{HeaderLevel: headerLevel}, &content); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	existing := []byte("# Module\n\n## Resources\n\nold\n\n## Inputs\n\ninputs\n")
+
+	once, err := updateUnderHeading(existing, "## Resources", content.Bytes(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	twice, err := updateUnderHeading(once, "## Resources", content.Bytes(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(string(once), string(twice)); diff != "" {
+		t.Errorf("second update changed content -once +twice:\n%s", diff)
+	}
+
+	if !bytes.HasSuffix(twice, []byte("\n## Inputs\n\ninputs\n")) {
+		t.Errorf("following section not preserved:\n%s", twice)
+	}
+}
@@ -0,0 +1,292 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+)
+
+const (
+	// ChangeAdded marks resources which are not documented at the compare ref.
+	ChangeAdded = "+"
+	// ChangeRemoved marks resources which are only documented at the compare ref.
+	ChangeRemoved = "−"
+	// ChangeModified marks resources whose attributes differ from the compare ref.
+	ChangeModified = "~"
+)
+
+// RowChange is a documented resource which differs between the compare ref
+// and the working tree.
+type RowChange struct {
+	// Kind is ChangeAdded, ChangeRemoved or ChangeModified.
+	Kind string
+	// Row is the row in the working tree, or at the compare ref if removed.
+	Row *ResourceRow
+	// Attributes are the changed attributes and computed columns of modified rows.
+	Attributes []AttributeChange
+	// resourceType formats the values of the attributes.
+	resourceType *TerraformResourceType
+}
+
+// AttributeChange is the value of an attribute or computed column at the
+// compare ref and in the working tree.
+type AttributeChange struct {
+	Name string
+	Old  interface{}
+	New  interface{}
+}
+
+// diffRows compares the rows of each resource type at the compare ref with
+// those of the working tree by address. Changes are sorted by address.
+// Resource types without rows at the ref, as they cannot be compared, are
+// skipped.
+func diffRows(resourceTypes TerraformResources, base, head [][]*ResourceRow) []RowChange {
+	changes := []RowChange{}
+
+	for i, resourceType := range resourceTypes {
+		if base[i] == nil {
+			continue
+		}
+
+		baseRows := map[string]*ResourceRow{}
+		for _, row := range base[i] {
+			baseRows[row.Address()] = row
+		}
+
+		headRows := map[string]bool{}
+		typeChanges := []RowChange{}
+
+		for _, row := range head[i] {
+			headRows[row.Address()] = true
+
+			old, ok := baseRows[row.Address()]
+			if !ok {
+				typeChanges = append(typeChanges, RowChange{Kind: ChangeAdded, Row: row, resourceType: resourceType})
+				continue
+			}
+
+			if attributes := diffAttributes(resourceType, old, row); len(attributes) > 0 {
+				typeChanges = append(typeChanges, RowChange{Kind: ChangeModified, Row: row, Attributes: attributes, resourceType: resourceType})
+			}
+		}
+
+		for _, row := range base[i] {
+			if !headRows[row.Address()] {
+				typeChanges = append(typeChanges, RowChange{Kind: ChangeRemoved, Row: row, resourceType: resourceType})
+			}
+		}
+
+		sort.SliceStable(typeChanges, func(a, b int) bool {
+			return typeChanges[a].Row.Name < typeChanges[b].Row.Name
+		})
+
+		changes = append(changes, typeChanges...)
+	}
+
+	return changes
+}
+
+// diffAttributes returns the documented attributes and computed columns
+// whose values differ between the rows.
+func diffAttributes(resourceType *TerraformResourceType, old, new *ResourceRow) []AttributeChange {
+	var changes []AttributeChange
+
+	for _, name := range resourceType.Attributes {
+		if !equalValues(old.Attributes[name], new.Attributes[name]) {
+			changes = append(changes, AttributeChange{Name: name, Old: old.Attributes[name], New: new.Attributes[name]})
+		}
+	}
+
+	for _, column := range resourceType.Columns {
+		if !equalValues(old.Computed[column.Title], new.Computed[column.Title]) {
+			changes = append(changes, AttributeChange{Name: column.Title, Old: old.Computed[column.Title], New: new.Computed[column.Title]})
+		}
+	}
+
+	return changes
+}
+
+// equalValues reports whether two values returned by the parser or computed
+// expressions are equal, comparing unknown values by their source.
+func equalValues(a, b interface{}) bool {
+	switch a := a.(type) {
+	case *big.Float:
+		b, ok := b.(*big.Float)
+		return ok && a.Cmp(b) == 0
+	case *terraform.UnknownAttributeValue:
+		b, ok := b.(*terraform.UnknownAttributeValue)
+		return ok && a.Source == b.Source
+	default:
+		return a == b
+	}
+}
+
+// changesMarkdown renders the changes as a table, marking each resource as
+// added, removed or modified. If mdx is set, values are rendered as MDX.
+func changesMarkdown(ref string, changes []RowChange, mdx bool) string {
+	if len(changes) == 0 {
+		return fmt.Sprintf("No documented resources changed since %s.\n", codeSpan(ref))
+	}
+
+	var b strings.Builder
+
+	b.WriteString("| | **Address** | **Changes** |\n| --- | --- | --- |\n")
+	for _, change := range changes {
+		cells := make([]string, len(change.Attributes))
+		for i, attribute := range change.Attributes {
+			cells[i] = fmt.Sprintf("%s: %s → %s",
+				codeSpan(attribute.Name),
+				changeValue(change.resourceType, attribute.Name, attribute.Old, mdx),
+				changeValue(change.resourceType, attribute.Name, attribute.New, mdx),
+			)
+		}
+
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", change.Kind, codeSpan(change.Row.Address()), strings.Join(cells, lineBreak(mdx)))
+	}
+
+	return b.String()
+}
+
+// changeValue renders a changed value, showing the source of unknown values.
+func changeValue(resourceType *TerraformResourceType, key string, value interface{}, mdx bool) string {
+	switch v := value.(type) {
+	case nil:
+		return "_unset_"
+	case *terraform.UnknownAttributeValue:
+		return codeSpan(v.Source)
+	default:
+		return resourceType.formatValue(key, value, mdx)
+	}
+}
+
+// ChangesSummaryMarkdown renders the changes of a module since the compare
+// ref for the job summary, under a heading naming the module if set.
+func ChangesSummaryMarkdown(module string, ref string, changes string) string {
+	if module == "" {
+		return fmt.Sprintf("## Resource changes since %s\n\n%s\n", codeSpan(ref), changes)
+	}
+
+	return fmt.Sprintf("## Resource changes in %s since %s\n\n%s\n", codeSpan(filepath.ToSlash(filepath.Clean(module))), codeSpan(ref), changes)
+}
+
+// compareWorktree checks out the compare ref in a temporary git worktree,
+// once per run. It returns the root of the repository containing dir and the
+// root of the worktree.
+func (r *runner) compareWorktree(ctx context.Context, dir string, ref string) (string, string, error) {
+	r.compare.Do(func() {
+		out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+		if err != nil {
+			r.compareErr = fmt.Errorf("failed to find git repository: %w", err)
+			return
+		}
+
+		r.compareRoot = strings.TrimSpace(string(out))
+
+		worktree, err := os.MkdirTemp("", "tf-resource-table-")
+		if err != nil {
+			r.compareErr = fmt.Errorf("failed to create worktree directory: %w", err)
+			return
+		}
+
+		if out, err := exec.CommandContext(ctx, "git", "-C", r.compareRoot, "worktree", "add", "--detach", worktree, ref).CombinedOutput(); err != nil {
+			os.RemoveAll(worktree)
+			r.compareErr = fmt.Errorf("failed to check out %s: %w: %s", ref, err, strings.TrimSpace(string(out)))
+			return
+		}
+
+		r.compareDir = worktree
+	})
+
+	return r.compareRoot, r.compareDir, r.compareErr
+}
+
+// removeWorktree removes the worktree of the compare ref, if checked out. It
+// does not use the context of the run, so that the worktree is removed even if
+// the run is cancelled, then prunes the metadata of removed worktrees.
+func (r *runner) removeWorktree(reporter Reporter) {
+	if r.compareDir == "" {
+		return
+	}
+
+	if out, err := exec.Command("git", "-C", r.compareRoot, "worktree", "remove", "--force", r.compareDir).CombinedOutput(); err != nil {
+		reporter.Infof("failed to remove worktree %s: %v: %s", r.compareDir, err, strings.TrimSpace(string(out)))
+	}
+
+	os.RemoveAll(r.compareDir)
+
+	if out, err := exec.Command("git", "-C", r.compareRoot, "worktree", "prune").CombinedOutput(); err != nil {
+		reporter.Infof("failed to prune worktrees: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+}
+
+// compareRows builds the rows of the resource types for the module at the
+// compare ref, using the provider schemas of the working tree. A module which
+// does not exist at the ref has no rows, and resource types whose schema is
+// not in the provider schemas of the working tree, e.g. as the source of their
+// provider changed, have nil rows.
+func (r *runner) compareRows(ctx context.Context, inputs Inputs, schemas *tfjson.ProviderSchemas, filter PathFilter, resourceTypes TerraformResources, reporter Reporter) ([][]*ResourceRow, error) {
+	root, worktree, err := r.compareWorktree(ctx, inputs.WorkingDirectory, inputs.CompareRef)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := filepath.Abs(inputs.WorkingDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsByType := make([][]*ResourceRow, len(resourceTypes))
+	for i := range rowsByType {
+		rowsByType[i] = []*ResourceRow{}
+	}
+
+	dir = filepath.Join(worktree, rel)
+	if !tfconfig.IsModuleDir(dir) {
+		reporter.Debugf("module does not exist at %s", inputs.CompareRef)
+		return rowsByType, nil
+	}
+
+	parser, err := terraform.NewParser(schemas)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parser: %w", err)
+	}
+
+	parser.SetFileFilter(filter.Keep)
+
+	if err := parser.LoadModule(dir); err != nil {
+		return nil, fmt.Errorf("failed to load module at %s: %w", inputs.CompareRef, err)
+	}
+
+	for i, resourceType := range resourceTypes {
+		rowsByType[i], err = resourceRows(reporter, parser, dir, resourceType)
+		if errors.Is(err, terraform.ErrSchemaNotFound) {
+			reporter.Infof("cannot compare %s with %s: %v", resourceType.Name, inputs.CompareRef, err)
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to document module at %s: %w", inputs.CompareRef, err)
+		}
+	}
+
+	return rowsByType, nil
+}
@@ -0,0 +1,191 @@
+package action
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDiffRows(t *testing.T) {
+	t.Parallel()
+
+	resourceTypes := TerraformResources{
+		{Name: "foo", Attributes: []string{"name", "threshold", "query"}},
+	}
+
+	row := func(name string, attrs map[string]interface{}) *ResourceRow {
+		return &ResourceRow{Type: "foo", Name: name, Attributes: attrs}
+	}
+
+	base := [][]*ResourceRow{{
+		row("a", map[string]interface{}{"name": "A", "threshold": big.NewFloat(1), "query": &terraform.UnknownAttributeValue{Source: "var.query"}}),
+		row("b", map[string]interface{}{"name": "B"}),
+		row("c", map[string]interface{}{"name": "C", "threshold": big.NewFloat(1)}),
+	}}
+
+	head := [][]*ResourceRow{{
+		row("a", map[string]interface{}{"name": "A", "threshold": big.NewFloat(1), "query": &terraform.UnknownAttributeValue{Source: "var.query"}}),
+		row("c", map[string]interface{}{"name": "C", "threshold": big.NewFloat(2), "query": "x"}),
+		row("d", map[string]interface{}{"name": "D"}),
+	}}
+
+	got := changesMarkdown("main", diffRows(resourceTypes, base, head), false)
+
+	want := "| | **Address** | **Changes** |\n| --- | --- | --- |\n" +
+		"| − | `foo.b` |  |\n" +
+		"| ~ | `foo.c` | `threshold`: 1 → 2<br>`query`: _unset_ → x |\n" +
+		"| + | `foo.d` |  |\n"
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected changes -want +got:\n%s", diff)
+	}
+
+	mdx := changesMarkdown("main", diffRows(resourceTypes, base, head), true)
+	if diff := cmp.Diff(strings.ReplaceAll(want, "<br>", "<br />"), mdx); diff != "" {
+		t.Errorf("unexpected MDX changes -want +got:\n%s", diff)
+	}
+
+	if got, want := changesMarkdown("main", diffRows(resourceTypes, base, base), false), "No documented resources changed since `main`.\n"; got != want {
+		t.Errorf("unexpected changes %q, want %q", got, want)
+	}
+}
+
+// compareRepository commits the module configuration base to a new git
+// repository, then writes head to the working tree. It returns the module
+// directory.
+func compareRepository(t *testing.T, base string, head string) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+
+	root := t.TempDir()
+	module := filepath.Join(root, "modules", "foo")
+
+	git := func(args ...string) {
+		t.Helper()
+
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=test", "-c", "user.email=test@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+
+	writeModule := func(config string) {
+		t.Helper()
+
+		if err := os.MkdirAll(module, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(module, "main.tf"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	git("init", "-q")
+	writeModule(base)
+	git("add", "-A")
+	git("commit", "-q", "-m", "base")
+	writeModule(head)
+
+	return module
+}
+
+// testProviderConfig requires the test provider from the given source.
+func testProviderConfig(source string) string {
+	return `
+terraform {
+	required_providers {
+		test = {
+			source = "` + source + `"
+		}
+	}
+}
+`
+}
+
+var testProviderSchemas = &tfjson.ProviderSchemas{
+	Schemas: map[string]*tfjson.ProviderSchema{
+		"registry.terraform.io/test/test": {
+			ResourceSchemas: map[string]*tfjson.Schema{
+				"test_resource": {
+					Block: &tfjson.SchemaBlock{
+						Attributes: map[string]*tfjson.SchemaAttribute{
+							"name": {AttributeType: cty.String},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func TestRunner_CompareRows(t *testing.T) {
+	t.Parallel()
+
+	module := compareRepository(t,
+		testProviderConfig("test/test")+`resource "test_resource" "a" { name = "old" }`,
+		testProviderConfig("test/test")+`resource "test_resource" "a" { name = "new" }`,
+	)
+
+	ctx := context.Background()
+	r := &runner{}
+	t.Cleanup(func() { r.removeWorktree(testReporter{t}) })
+
+	inputs := Inputs{WorkingDirectory: module, CompareRef: "HEAD"}
+	resourceTypes := TerraformResources{{Name: "test_resource", Attributes: []string{"name"}}}
+
+	rows, err := r.compareRows(ctx, inputs, testProviderSchemas, PathFilter{}, resourceTypes, testReporter{t})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows[0]) != 1 {
+		t.Fatalf("unexpected rows %v", rows[0])
+	}
+
+	if got, want := rows[0][0].Attributes["name"], "old"; got != want {
+		t.Errorf("unexpected name %v, want %v", got, want)
+	}
+}
+
+func TestRunner_CompareRows_ChangedProviderSource(t *testing.T) {
+	t.Parallel()
+
+	module := compareRepository(t,
+		testProviderConfig("oldns/test")+`resource "test_resource" "a" { name = "old" }`,
+		testProviderConfig("test/test")+`resource "test_resource" "a" { name = "new" }`,
+	)
+
+	ctx := context.Background()
+	r := &runner{}
+	t.Cleanup(func() { r.removeWorktree(testReporter{t}) })
+
+	inputs := Inputs{WorkingDirectory: module, CompareRef: "HEAD"}
+	resourceTypes := TerraformResources{{Name: "test_resource", Attributes: []string{"name"}}}
+
+	rows, err := r.compareRows(ctx, inputs, testProviderSchemas, PathFilter{}, resourceTypes, testReporter{t})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rows[0] != nil {
+		t.Errorf("unexpected rows %v of a resource type which cannot be compared", rows[0])
+	}
+
+	head := [][]*ResourceRow{{{Type: "test_resource", Name: "a"}}}
+	if changes := diffRows(resourceTypes, rows, head); len(changes) != 0 {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
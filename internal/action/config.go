@@ -0,0 +1,233 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileName is the name of the configuration files discovered in the
+// working directory and its parent directories.
+const ConfigFileName = ".tf-resource-table.yml"
+
+const (
+	// DefaultOutputFile is the output file if neither an input nor a configuration file sets one.
+	DefaultOutputFile = "README.md"
+	// NoOutputFile disables writing to an output file.
+	NoOutputFile = "-"
+	// DefaultHeaderLevel is the level of the header preceding each table,
+	// if neither an input nor a configuration file sets one.
+	DefaultHeaderLevel = 2
+)
+
+// Config is a configuration file. Settings of files in subdirectories take
+// precedence over those of their parent directories, and inputs take
+// precedence over configuration files.
+type Config struct {
+	// Root stops the discovery of configuration files in parent directories.
+	Root bool `yaml:"root"`
+	// OutputFile is relative to the working directory.
+	OutputFile  string `yaml:"output_file"`
+	HeaderLevel int    `yaml:"header_level"`
+	// RequireFence is nil if unset, so that a subdirectory may turn it off.
+	RequireFence  *bool  `yaml:"require_fence"`
+	FenceStyle    string `yaml:"fence_style"`
+	FenceBegin    string `yaml:"fence_begin"`
+	FenceEnd      string `yaml:"fence_end"`
+	AnchorHeading string `yaml:"anchor_heading"`
+	LinkMode      string `yaml:"link_mode"`
+	LinkRef       string `yaml:"link_ref"`
+	// Include and Exclude are patterns of the files and directories of
+	// modules to document, see PathFilter.
+	Include []string `yaml:"include"`
+	Exclude []string `yaml:"exclude"`
+	// Format is the format of resource types which do not set their own.
+	Format ValueFormat `yaml:"format"`
+	// Resources are merged by name, resource types of subdirectories replacing
+	// those of the same name in parent directories.
+	Resources TerraformResources `yaml:"resources"`
+}
+
+// LoadConfig discovers the configuration files in dir and its parent
+// directories, up to the root of the repository or a file setting root, and
+// returns their merged configuration along with the paths of the files.
+func LoadConfig(dir string) (*Config, []string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var configs []*Config
+	var paths []string
+
+	for {
+		path := filepath.Join(abs, ConfigFileName)
+
+		config, err := readConfig(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+
+		if config != nil {
+			configs = append([]*Config{config}, configs...)
+			paths = append([]string{path}, paths...)
+
+			if config.Root {
+				break
+			}
+		}
+
+		if _, err := os.Stat(filepath.Join(abs, ".git")); err == nil {
+			break
+		}
+
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			break
+		}
+
+		abs = parent
+	}
+
+	merged := &Config{}
+	for _, config := range configs {
+		merged.merge(config)
+	}
+
+	return merged, paths, nil
+}
+
+func readConfig(path string) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(b))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// merge overrides the settings of c with those set in child.
+func (c *Config) merge(child *Config) {
+	setString := func(dst *string, src string) {
+		if src != "" {
+			*dst = src
+		}
+	}
+
+	setString(&c.OutputFile, child.OutputFile)
+	setString(&c.FenceStyle, child.FenceStyle)
+	setString(&c.FenceBegin, child.FenceBegin)
+	setString(&c.FenceEnd, child.FenceEnd)
+	setString(&c.AnchorHeading, child.AnchorHeading)
+	setString(&c.LinkMode, child.LinkMode)
+	setString(&c.LinkRef, child.LinkRef)
+
+	if child.HeaderLevel != 0 {
+		c.HeaderLevel = child.HeaderLevel
+	}
+
+	if child.RequireFence != nil {
+		c.RequireFence = child.RequireFence
+	}
+
+	if len(child.Include) > 0 {
+		c.Include = child.Include
+	}
+
+	c.Exclude = append(c.Exclude, child.Exclude...)
+
+	if child.Format != (ValueFormat{}) {
+		c.Format = child.Format
+	}
+
+	for _, resource := range child.Resources {
+		replaced := false
+		for i := range c.Resources {
+			if c.Resources[i].Name == resource.Name {
+				c.Resources[i] = resource
+				replaced = true
+			}
+		}
+
+		if !replaced {
+			c.Resources = append(c.Resources, resource)
+		}
+	}
+}
+
+// WithConfig returns the inputs with the settings they do not set taken from
+// the configuration, then from the defaults. An OutputFile of NoOutputFile
+// is replaced with an empty string.
+func (i Inputs) WithConfig(c *Config) Inputs {
+	setString := func(dst *string, values ...string) {
+		for _, value := range values {
+			if *dst != "" {
+				return
+			}
+
+			*dst = value
+		}
+	}
+
+	setString(&i.OutputFile, c.OutputFile, DefaultOutputFile)
+	setString(&i.FenceStyle, c.FenceStyle)
+	setString(&i.FenceBegin, c.FenceBegin)
+	setString(&i.FenceEnd, c.FenceEnd)
+	setString(&i.AnchorHeading, c.AnchorHeading)
+	setString(&i.LinkMode, c.LinkMode)
+	setString(&i.LinkRef, c.LinkRef)
+	setString(&i.Include, strings.Join(c.Include, "\n"))
+	setString(&i.Exclude, strings.Join(c.Exclude, "\n"))
+
+	if i.OutputFile == NoOutputFile {
+		i.OutputFile = ""
+	}
+
+	if i.HeaderLevel == 0 {
+		i.HeaderLevel = c.HeaderLevel
+	}
+
+	if i.HeaderLevel == 0 {
+		i.HeaderLevel = DefaultHeaderLevel
+	}
+
+	if i.RequireFence == nil {
+		i.RequireFence = c.RequireFence
+	}
+
+	return i
+}
+
+// resourceTypes returns the resource types of the inputs, or of the
+// configuration if the inputs do not set any, with the default format of the
+// configuration applied.
+func (c *Config) resourceTypes(input TerraformResources) TerraformResources {
+	resources := input
+	if len(resources) == 0 {
+		resources = append(TerraformResources{}, c.Resources...)
+	}
+
+	for _, resource := range resources {
+		if resource.Format == (ValueFormat{}) {
+			resource.Format = c.Format
+		}
+	}
+
+	return resources
+}
@@ -0,0 +1,151 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	module := filepath.Join(root, "modules", "monitors")
+
+	files := map[string]string{
+		filepath.Join(root, ".git", "HEAD"): "ref: refs/heads/main\n",
+		filepath.Join(root, ConfigFileName): `
+output_file: docs.md
+header_level: 3
+require_fence: true
+exclude: ['*_test.tf']
+format:
+  max_length: 10
+resources:
+  - name: foo
+    attributes: [a]
+  - name: bar
+    attributes: [b]
+`,
+		filepath.Join(root, "modules", ConfigFileName): `
+resources:
+  - name: foo
+    attributes: [c]
+`,
+		filepath.Join(module, ConfigFileName): `
+output_file: README.md
+link_mode: permalink
+require_fence: false
+exclude: [examples/**]
+`,
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, paths, err := LoadConfig(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	requireFence := false
+
+	want := &Config{
+		OutputFile:   "README.md",
+		HeaderLevel:  3,
+		RequireFence: &requireFence,
+		LinkMode:     LinkModePermalink,
+		Exclude:      []string{"*_test.tf", "examples/**"},
+		Format:       ValueFormat{MaxLength: 10},
+		Resources: TerraformResources{
+			{Name: "foo", Attributes: []string{"c"}},
+			{Name: "bar", Attributes: []string{"b"}},
+		},
+	}
+
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(TerraformResourceType{})); diff != "" {
+		t.Errorf("unexpected config -want +got:\n%s", diff)
+	}
+
+	wantPaths := []string{
+		filepath.Join(root, ConfigFileName),
+		filepath.Join(root, "modules", ConfigFileName),
+		filepath.Join(module, ConfigFileName),
+	}
+
+	if diff := cmp.Diff(wantPaths, paths); diff != "" {
+		t.Errorf("unexpected paths -want +got:\n%s", diff)
+	}
+}
+
+func TestInputs_WithConfig(t *testing.T) {
+	t.Parallel()
+
+	requireFence, noRequireFence := true, false
+
+	tests := []struct {
+		name   string
+		inputs Inputs
+		config *Config
+		want   Inputs
+	}{
+		{
+			name:   "defaults",
+			inputs: Inputs{},
+			config: &Config{},
+			want:   Inputs{OutputFile: DefaultOutputFile, HeaderLevel: DefaultHeaderLevel},
+		},
+		{
+			name:   "config",
+			inputs: Inputs{},
+			config: &Config{OutputFile: "docs.md", HeaderLevel: 3, LinkMode: LinkModePermalink, RequireFence: &requireFence},
+			want:   Inputs{OutputFile: "docs.md", HeaderLevel: 3, LinkMode: LinkModePermalink, RequireFence: &requireFence},
+		},
+		{
+			name:   "inputs override config",
+			inputs: Inputs{OutputFile: "other.md", HeaderLevel: 4},
+			config: &Config{OutputFile: "docs.md", HeaderLevel: 3},
+			want:   Inputs{OutputFile: "other.md", HeaderLevel: 4},
+		},
+		{
+			name:   "require fence turned off",
+			inputs: Inputs{},
+			config: &Config{RequireFence: &noRequireFence},
+			want:   Inputs{OutputFile: DefaultOutputFile, HeaderLevel: DefaultHeaderLevel, RequireFence: &noRequireFence},
+		},
+		{
+			name:   "require fence input overrides config",
+			inputs: Inputs{RequireFence: &noRequireFence},
+			config: &Config{RequireFence: &requireFence},
+			want:   Inputs{OutputFile: DefaultOutputFile, HeaderLevel: DefaultHeaderLevel, RequireFence: &noRequireFence},
+		},
+		{
+			name:   "no output file",
+			inputs: Inputs{OutputFile: NoOutputFile},
+			config: &Config{OutputFile: "docs.md"},
+			want:   Inputs{OutputFile: "", HeaderLevel: DefaultHeaderLevel},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.inputs.WithConfig(tc.config)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected inputs -want +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,503 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrFenceNotFound is returned if a section's fences are required but not found.
+var ErrFenceNotFound = errors.New("fence not found")
+
+const (
+	// FenceStyleHTML fences sections with HTML comments, e.g. `<!-- BEGIN_TF_RESOURCE_TABLES -->`.
+	FenceStyleHTML = "html"
+	// FenceStyleMDX fences sections with MDX comments, e.g. `{/* BEGIN_TF_RESOURCE_TABLES */}`.
+	FenceStyleMDX = "mdx"
+	// FenceStyleRST fences sections with reStructuredText comments, e.g. `.. BEGIN_TF_RESOURCE_TABLES`.
+	FenceStyleRST = "rst"
+)
+
+// Markers are the comments fencing sections of the output file.
+type Markers struct {
+	// Prefix and Suffix delimit each comment. Suffix may be empty.
+	Prefix string
+	Suffix string
+	// Begin and End are the keywords of the comments beginning and ending a section.
+	Begin string
+	End   string
+}
+
+// DefaultMarkers fence sections with `<!-- BEGIN_TF_RESOURCE_TABLES -->` and
+// `<!-- END_TF_RESOURCE_TABLES -->`.
+var DefaultMarkers = Markers{
+	Prefix: "<!--",
+	Suffix: "-->",
+	Begin:  "BEGIN_TF_RESOURCE_TABLES",
+	End:    "END_TF_RESOURCE_TABLES",
+}
+
+// NewMarkers returns the markers of the given comment style, with the given
+// keywords replacing the defaults if not empty.
+func NewMarkers(style string, begin string, end string) (Markers, error) {
+	m := DefaultMarkers
+
+	switch style {
+	case "", FenceStyleHTML:
+	case FenceStyleMDX:
+		m.Prefix, m.Suffix = "{/*", "*/}"
+	case FenceStyleRST:
+		m.Prefix, m.Suffix = "..", ""
+	default:
+		return Markers{}, fmt.Errorf("unknown fence style %q", style)
+	}
+
+	if begin != "" {
+		m.Begin = begin
+	}
+
+	if end != "" {
+		m.End = end
+	}
+
+	for _, keyword := range []string{m.Begin, m.End} {
+		if strings.IndexFunc(keyword, unicode.IsSpace) != -1 || strings.Contains(keyword, "=") {
+			return Markers{}, fmt.Errorf("invalid fence keyword %q", keyword)
+		}
+	}
+
+	if m.Begin == m.End {
+		return Markers{}, fmt.Errorf("fence begin and end keywords must differ, got %q", m.Begin)
+	}
+
+	return m, nil
+}
+
+// Section is the generated content of a named fenced section.
+// The unnamed section is fenced by markers without a name.
+type Section struct {
+	Name string
+	// Params are the parameters of a fence configuring its own table, see fencedResources.
+	Params  string
+	Content []byte
+}
+
+// fence is the location of a fenced section in a document.
+type fence struct {
+	name   string
+	params string
+	// line is the line number of the begin marker.
+	line int
+	// contentStart is the offset of the line following the begin marker.
+	contentStart int
+	// contentEnd is the offset of the line containing the end marker.
+	contentEnd int
+}
+
+// beginMarker returns the marker beginning the named section, e.g.
+// `<!-- BEGIN_TF_RESOURCE_TABLES monitors -->`.
+func (m Markers) beginMarker(name string, params string) string {
+	return m.marker(m.Begin, name, params)
+}
+
+func (m Markers) endMarker(name string) string {
+	return m.marker(m.End, name, "")
+}
+
+func (m Markers) marker(keyword string, name string, params string) string {
+	fields := []string{m.Prefix, keyword}
+	for _, field := range []string{name, params, m.Suffix} {
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// parseMarker returns the section name and parameters of a line consisting of
+// a marker with the given keyword, e.g.
+// `<!-- BEGIN_TF_RESOURCE_TABLES monitors type=observe_monitor attributes=name -->`.
+func (m Markers) parseMarker(line string, keyword string) (string, string, bool) {
+	line = strings.TrimSpace(line)
+
+	inner := strings.TrimPrefix(line, m.Prefix)
+	if inner == line || !strings.HasSuffix(inner, m.Suffix) {
+		return "", "", false
+	}
+
+	fields := markerFields(strings.TrimSuffix(inner, m.Suffix))
+	if len(fields) == 0 || fields[0] != keyword {
+		return "", "", false
+	}
+
+	fields = fields[1:]
+
+	name := ""
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		name, fields = fields[0], fields[1:]
+	}
+
+	return name, strings.Join(fields, " "), true
+}
+
+// markerFields splits s around whitespace outside of double quotes.
+func markerFields(s string) []string {
+	var fields []string
+	var field strings.Builder
+	quoted, inField := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+
+			continue
+		}
+
+		field.WriteRune(r)
+		inField = true
+	}
+
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
+
+// FenceError is a malformed, nested or duplicated fence.
+type FenceError struct {
+	// Line is the 1-based line number of the offending marker.
+	Line    int
+	Message string
+}
+
+func (e *FenceError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// findFences returns the fenced sections in the document, in order.
+// Every begin marker must be followed by an end marker with the same name
+// before the next begin marker, and each section may only be fenced once.
+func findFences(b []byte, m Markers) ([]fence, error) {
+	var fences []fence
+	var open *fence
+	seen := map[sectionKey]int{}
+	code := &codeBlocks{}
+
+	offset := 0
+	for i, line := range bytes.SplitAfter(b, []byte("\n")) {
+		lineNumber := i + 1
+		lineStart := offset
+		offset += len(line)
+
+		if code.skip(string(line)) {
+			continue
+		}
+
+		if name, params, ok := m.parseMarker(string(line), m.Begin); ok {
+			if open != nil {
+				return nil, &FenceError{lineNumber, fmt.Sprintf("%s is nested in %s opened at line %d", m.beginMarker(name, params), m.beginMarker(open.name, open.params), open.line)}
+			}
+
+			key := sectionKey{name, params}
+			if previous, ok := seen[key]; ok {
+				return nil, &FenceError{lineNumber, fmt.Sprintf("%s duplicates the fence at line %d", m.beginMarker(name, params), previous)}
+			}
+
+			seen[key] = lineNumber
+			open = &fence{name: name, params: params, line: lineNumber, contentStart: offset}
+			continue
+		}
+
+		if name, _, ok := m.parseMarker(string(line), m.End); ok {
+			if open == nil {
+				return nil, &FenceError{lineNumber, fmt.Sprintf("%s has no preceding %s", m.endMarker(name), m.beginMarker(name, ""))}
+			}
+
+			if name != open.name {
+				return nil, &FenceError{lineNumber, fmt.Sprintf("%s does not match %s opened at line %d", m.endMarker(name), m.beginMarker(open.name, open.params), open.line)}
+			}
+
+			open.contentEnd = lineStart
+			fences = append(fences, *open)
+			open = nil
+		}
+	}
+
+	if open != nil {
+		return nil, &FenceError{open.line, fmt.Sprintf("%s has no matching %s", m.beginMarker(open.name, open.params), m.endMarker(open.name))}
+	}
+
+	return fences, nil
+}
+
+// codeBlocks tracks the fenced code blocks of a document, whose lines are
+// examples rather than fences or headings.
+type codeBlocks struct {
+	// fence is the fence of the open code block, e.g. "```", if any.
+	fence string
+}
+
+// skip reports whether the line opens, closes or is within a code block.
+func (c *codeBlocks) skip(line string) bool {
+	trimmed := strings.TrimSpace(line)
+
+	if c.fence != "" {
+		// the closing fence has at least as many of the same characters
+		if strings.HasPrefix(trimmed, c.fence) && strings.Trim(trimmed, c.fence[:1]) == "" {
+			c.fence = ""
+		}
+
+		return true
+	}
+
+	for _, char := range []string{"`", "~"} {
+		if strings.HasPrefix(trimmed, strings.Repeat(char, 3)) {
+			c.fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, char))]
+			return true
+		}
+	}
+
+	return false
+}
+
+// sectionKey identifies the fences of a section.
+type sectionKey struct {
+	name   string
+	params string
+}
+
+// updateContent returns the existing content with the content of each section
+// between its fences. Sections which are not found are appended, unless
+// requireFence is set.
+// Fences configuring their own table are matched by their parameters as well
+// as their name, so that each is replaced with the table it configures.
+func updateContent(existing []byte, sections []Section, m Markers, requireFence bool, reporter Reporter) ([]byte, error) {
+	all, err := findFences(existing, m)
+	if err != nil {
+		return nil, err
+	}
+
+	fences := map[sectionKey]fence{}
+	for _, f := range all {
+		fences[sectionKey{f.name, f.params}] = f
+	}
+
+	updated := existing
+	var appended [][]byte
+
+	found := []fence{}
+	contents := map[sectionKey][]byte{}
+	for _, section := range sections {
+		key := sectionKey{section.Name, section.Params}
+
+		f, ok := fences[key]
+		if !ok {
+			if requireFence {
+				return nil, fmt.Errorf("%w: %s", ErrFenceNotFound, m.beginMarker(section.Name, section.Params))
+			}
+
+			reporter.Debugf("comment fences for section %q not found, appending to file", section.Name)
+			appended = append(appended, fencedSection(section, m))
+			continue
+		}
+
+		reporter.Debugf("comment fences for section %q found (start = %d, end = %d), updating file", section.Name, f.contentStart, f.contentEnd)
+		found = append(found, f)
+		contents[key] = section.Content
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].contentStart > found[j].contentStart
+	})
+
+	// replace from the end of the document, so that offsets remain valid
+	for _, f := range found {
+		var b bytes.Buffer
+
+		if err := writeBytes(
+			&b,
+			updated[:f.contentStart],
+			newlineIfMissing(updated[:f.contentStart]),
+			contents[sectionKey{f.name, f.params}],
+			[]byte("\n"),
+			updated[f.contentEnd:],
+		); err != nil {
+			return nil, err
+		}
+
+		updated = b.Bytes()
+	}
+
+	for _, section := range appended {
+		var b bytes.Buffer
+
+		if err := writeBytes(&b, withTrailingNewline(updated), section); err != nil {
+			return nil, err
+		}
+
+		updated = b.Bytes()
+	}
+
+	return updated, nil
+}
+
+func fencedSection(section Section, m Markers) []byte {
+	return bytes.Join([][]byte{
+		[]byte(m.beginMarker(section.Name, section.Params)),
+		[]byte("\n"),
+		section.Content,
+		[]byte("\n"),
+		[]byte(m.endMarker(section.Name)),
+		[]byte("\n"),
+	}, nil)
+}
+
+func newlineIfMissing(b []byte) []byte {
+	if len(b) == 0 || bytes.HasSuffix(b, []byte("\n")) {
+		return nil
+	}
+
+	return []byte("\n")
+}
+
+func withTrailingNewline(b []byte) []byte {
+	if len(b) == 0 {
+		return b
+	}
+
+	return bytes.Join([][]byte{b, []byte("\n")}, []byte{})
+}
+
+// fenceParams maps the parameters of a fence to the YAML paths of the
+// resource type options they configure.
+var fenceParams = map[string][]string{
+	"type":                {"name"},
+	"attributes":          {"attributes"},
+	"description":         {"description"},
+	"identifier":          {"identifier"},
+	"meta":                {"meta"},
+	"link_attributes":     {"link_attributes"},
+	"markdown":            {"markdown"},
+	"header_level":        {"header_level"},
+	"true":                {"format", "true"},
+	"false":               {"format", "false"},
+	"thousands_separator": {"format", "thousands_separator"},
+	"precision":           {"format", "precision"},
+	"multiline":           {"format", "multiline"},
+	"max_length":          {"format", "max_length"},
+}
+
+// fenceListParams are the parameters whose values are comma-separated lists.
+var fenceListParams = map[string]bool{
+	"attributes": true,
+	"meta":       true,
+	"markdown":   true,
+}
+
+// fencedResources returns the resource types configured by the parameters of
+// the fences in the document, e.g.
+// `<!-- BEGIN_TF_RESOURCE_TABLES type=observe_monitor attributes=name,description -->`.
+// Each is written between its own fences.
+func fencedResources(b []byte, m Markers) (TerraformResources, error) {
+	fences, err := findFences(b, m)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := TerraformResources{}
+	for _, f := range fences {
+		if f.params == "" {
+			continue
+		}
+
+		resource, err := parseFenceParams(f.params)
+		if err != nil {
+			return nil, &FenceError{f.line, fmt.Sprintf("invalid parameters: %s", err)}
+		}
+
+		resource.Section = f.name
+		resource.params = f.params
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// parseFenceParams parses space-separated `key=value` parameters into a resource type.
+func parseFenceParams(params string) (*TerraformResourceType, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, param := range markerFields(params) {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("parameter %q must be of the form key=value", param)
+		}
+
+		path, ok := fenceParams[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %q", key)
+		}
+
+		value = strings.ReplaceAll(value, `"`, "")
+
+		node := &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		if fenceListParams[key] {
+			node = &yaml.Node{Kind: yaml.SequenceNode}
+			for _, item := range strings.Split(value, ",") {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(item)})
+			}
+		}
+
+		setYAMLPath(doc, path, node)
+	}
+
+	resource := &TerraformResourceType{}
+	if err := doc.Decode(resource); err != nil {
+		return nil, err
+	}
+
+	if resource.Name == "" {
+		return nil, errors.New("parameter \"type\" is required")
+	}
+
+	return resource, nil
+}
+
+// setYAMLPath sets the value at the path of nested mappings, creating them as necessary.
+func setYAMLPath(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == path[0] {
+			if len(path) == 1 {
+				mapping.Content[i+1] = value
+			} else {
+				setYAMLPath(mapping.Content[i+1], path[1:], value)
+			}
+
+			return
+		}
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, key, value)
+		return
+	}
+
+	child := &yaml.Node{Kind: yaml.MappingNode}
+	setYAMLPath(child, path[1:], value)
+	mapping.Content = append(mapping.Content, key, child)
+}
@@ -0,0 +1,368 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUpdateContent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		existing     string
+		sections     []Section
+		markers      *Markers
+		requireFence bool
+		want         string
+		wantErr      string
+	}{
+		{
+			name:     "empty",
+			existing: "",
+			sections: []Section{{Content: []byte("tables\n")}},
+			want:     "<!-- BEGIN_TF_RESOURCE_TABLES -->\ntables\n\n<!-- END_TF_RESOURCE_TABLES -->\n",
+		},
+		{
+			name:     "append",
+			existing: "existing\n",
+			sections: []Section{{Content: []byte("tables\n")}},
+			want:     "existing\n\n<!-- BEGIN_TF_RESOURCE_TABLES -->\ntables\n\n<!-- END_TF_RESOURCE_TABLES -->\n",
+		},
+		{
+			name:     "replace",
+			existing: "leading\n<!-- BEGIN_TF_RESOURCE_TABLES -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\ntrailing\n",
+			sections: []Section{{Content: []byte("tables\n")}},
+			want:     "leading\n<!-- BEGIN_TF_RESOURCE_TABLES -->\ntables\n\n<!-- END_TF_RESOURCE_TABLES -->\ntrailing\n",
+		},
+		{
+			name: "replace named sections",
+			existing: "# Monitors\n<!-- BEGIN_TF_RESOURCE_TABLES monitors -->\nold\n<!-- END_TF_RESOURCE_TABLES monitors -->\n" +
+				"# Datasets\n<!-- BEGIN_TF_RESOURCE_TABLES datasets -->\n<!-- END_TF_RESOURCE_TABLES datasets -->\n",
+			sections: []Section{
+				{Name: "datasets", Content: []byte("datasets\n")},
+				{Name: "monitors", Content: []byte("monitors\n")},
+			},
+			want: "# Monitors\n<!-- BEGIN_TF_RESOURCE_TABLES monitors -->\nmonitors\n\n<!-- END_TF_RESOURCE_TABLES monitors -->\n" +
+				"# Datasets\n<!-- BEGIN_TF_RESOURCE_TABLES datasets -->\ndatasets\n\n<!-- END_TF_RESOURCE_TABLES datasets -->\n",
+		},
+		{
+			name:     "append named section",
+			existing: "<!-- BEGIN_TF_RESOURCE_TABLES -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			sections: []Section{
+				{Content: []byte("tables\n")},
+				{Name: "monitors", Content: []byte("monitors\n")},
+			},
+			want: "<!-- BEGIN_TF_RESOURCE_TABLES -->\ntables\n\n<!-- END_TF_RESOURCE_TABLES -->\n\n" +
+				"<!-- BEGIN_TF_RESOURCE_TABLES monitors -->\nmonitors\n\n<!-- END_TF_RESOURCE_TABLES monitors -->\n",
+		},
+		{
+			name: "replace configured fences",
+			existing: "<!-- BEGIN_TF_RESOURCE_TABLES type=foo attributes=a -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\n" +
+				"<!-- BEGIN_TF_RESOURCE_TABLES type=bar attributes=b -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			sections: []Section{
+				{Params: "type=foo attributes=a", Content: []byte("foo\n")},
+				{Params: "type=bar attributes=b", Content: []byte("bar\n")},
+			},
+			want: "<!-- BEGIN_TF_RESOURCE_TABLES type=foo attributes=a -->\nfoo\n\n<!-- END_TF_RESOURCE_TABLES -->\n" +
+				"<!-- BEGIN_TF_RESOURCE_TABLES type=bar attributes=b -->\nbar\n\n<!-- END_TF_RESOURCE_TABLES -->\n",
+		},
+		{
+			name:     "mdx markers",
+			existing: "{/* BEGIN_TF_RESOURCE_TABLES */}\nold\n{/* END_TF_RESOURCE_TABLES */}\n",
+			sections: []Section{{Content: []byte("tables\n")}},
+			markers:  &Markers{Prefix: "{/*", Suffix: "*/}", Begin: "BEGIN_TF_RESOURCE_TABLES", End: "END_TF_RESOURCE_TABLES"},
+			want:     "{/* BEGIN_TF_RESOURCE_TABLES */}\ntables\n\n{/* END_TF_RESOURCE_TABLES */}\n",
+		},
+		{
+			name:     "rst markers",
+			existing: "Title\n=====\n",
+			sections: []Section{{Name: "monitors", Content: []byte("tables\n")}},
+			markers:  &Markers{Prefix: "..", Begin: "BEGIN", End: "END"},
+			want:     "Title\n=====\n\n.. BEGIN monitors\ntables\n\n.. END monitors\n",
+		},
+		{
+			name:         "require fence",
+			existing:     "<!-- BEGIN_TF_RESOURCE_TABLES -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			sections:     []Section{{Name: "monitors", Content: []byte("monitors\n")}},
+			requireFence: true,
+			wantErr:      "fence not found: <!-- BEGIN_TF_RESOURCE_TABLES monitors -->",
+		},
+		{
+			name:     "mismatched names",
+			existing: "<!-- BEGIN_TF_RESOURCE_TABLES monitors -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			sections: []Section{{Name: "monitors", Content: []byte("monitors\n")}},
+			wantErr:  "line 3: <!-- END_TF_RESOURCE_TABLES --> does not match <!-- BEGIN_TF_RESOURCE_TABLES monitors --> opened at line 1",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			markers := DefaultMarkers
+			if tc.markers != nil {
+				markers = *tc.markers
+			}
+
+			got, err := updateContent([]byte(tc.existing), tc.sections, markers, tc.requireFence, testReporter{t})
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("updateContent() error = %v, want %q", err, tc.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, string(got)); diff != "" {
+				t.Errorf("unexpected content -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFencedResources(t *testing.T) {
+	t.Parallel()
+
+	precision := 2
+
+	tests := []struct {
+		name    string
+		content string
+		want    TerraformResources
+		wantErr bool
+	}{
+		{
+			name:    "unconfigured fences",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES -->\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			want:    TerraformResources{},
+		},
+		{
+			name: "configured fence in code block",
+			content: "~~~markdown\n" +
+				"<!-- BEGIN_TF_RESOURCE_TABLES type=observe_monitor attributes=name -->\n" +
+				"<!-- END_TF_RESOURCE_TABLES -->\n" +
+				"~~~\n",
+			want: TerraformResources{},
+		},
+		{
+			name: "configured fence",
+			content: "# Monitors\n" +
+				"<!-- BEGIN_TF_RESOURCE_TABLES type=observe_monitor attributes=name,description -->\n" +
+				"<!-- END_TF_RESOURCE_TABLES -->\n",
+			want: TerraformResources{
+				{
+					Name:       "observe_monitor",
+					Attributes: []string{"name", "description"},
+					params:     "type=observe_monitor attributes=name,description",
+				},
+			},
+		},
+		{
+			name: "named fence with options",
+			content: `<!-- BEGIN_TF_RESOURCE_TABLES monitors type=observe_monitor attributes=name ` +
+				`description=true meta=file,count header_level=3 precision=2 true="✅ yes" -->` + "\n" +
+				"<!-- END_TF_RESOURCE_TABLES monitors -->\n",
+			want: TerraformResources{
+				{
+					Name:        "observe_monitor",
+					Attributes:  []string{"name"},
+					Description: true,
+					Section:     "monitors",
+					Meta:        []string{"file", "count"},
+					HeaderLevel: 3,
+					Format:      ValueFormat{Precision: &precision, True: "✅ yes"},
+					params:      `type=observe_monitor attributes=name description=true meta=file,count header_level=3 precision=2 true="✅ yes"`,
+				},
+			},
+		},
+		{
+			name:    "missing type",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES attributes=a -->\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown parameter",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES type=foo colour=red -->\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES type=foo header_level=two -->\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := fencedResources([]byte(tc.content), DefaultMarkers)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("fencedResources() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(TerraformResourceType{})); diff != "" {
+				t.Errorf("unexpected resources -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFindFences(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		content string
+		want    []fence
+		wantErr string
+	}{
+		{
+			name:    "no fences",
+			content: "# Title\n",
+		},
+		{
+			name:    "fences",
+			content: "# Title\n<!-- BEGIN_TF_RESOURCE_TABLES -->\nold\n<!-- END_TF_RESOURCE_TABLES -->\n<!-- BEGIN_TF_RESOURCE_TABLES foo -->\n<!-- END_TF_RESOURCE_TABLES foo -->\n",
+			want: []fence{
+				{line: 2, contentStart: 42, contentEnd: 46},
+				{name: "foo", line: 5, contentStart: 116, contentEnd: 116},
+			},
+		},
+		{
+			name:    "fences in code blocks",
+			content: "```markdown\n<!-- BEGIN_TF_RESOURCE_TABLES -->\n```\n<!-- BEGIN_TF_RESOURCE_TABLES -->\n<!-- END_TF_RESOURCE_TABLES -->\n````\n```\n<!-- END_TF_RESOURCE_TABLES foo -->\n```\n````\n",
+			want: []fence{
+				{line: 4, contentStart: 84, contentEnd: 84},
+			},
+		},
+		{
+			name:    "begin without end",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES -->\ntables\n",
+			wantErr: "line 1: <!-- BEGIN_TF_RESOURCE_TABLES --> has no matching <!-- END_TF_RESOURCE_TABLES -->",
+		},
+		{
+			name:    "end before begin",
+			content: "<!-- END_TF_RESOURCE_TABLES -->\n<!-- BEGIN_TF_RESOURCE_TABLES -->\n",
+			wantErr: "line 1: <!-- END_TF_RESOURCE_TABLES --> has no preceding <!-- BEGIN_TF_RESOURCE_TABLES -->",
+		},
+		{
+			name:    "nested",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES -->\n<!-- BEGIN_TF_RESOURCE_TABLES foo -->\n<!-- END_TF_RESOURCE_TABLES foo -->\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			wantErr: "line 2: <!-- BEGIN_TF_RESOURCE_TABLES foo --> is nested in <!-- BEGIN_TF_RESOURCE_TABLES --> opened at line 1",
+		},
+		{
+			name:    "duplicated",
+			content: "<!-- BEGIN_TF_RESOURCE_TABLES -->\n<!-- END_TF_RESOURCE_TABLES -->\n\n<!-- BEGIN_TF_RESOURCE_TABLES -->\n<!-- END_TF_RESOURCE_TABLES -->\n",
+			wantErr: "line 4: <!-- BEGIN_TF_RESOURCE_TABLES --> duplicates the fence at line 1",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := findFences([]byte(tc.content), DefaultMarkers)
+			if tc.wantErr != "" {
+				if err == nil || err.Error() != tc.wantErr {
+					t.Fatalf("findFences() error = %v, want %q", err, tc.wantErr)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(fence{})); diff != "" {
+				t.Errorf("unexpected fences -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewMarkers(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		style   string
+		begin   string
+		end     string
+		want    Markers
+		wantErr bool
+	}{
+		{
+			name: "default",
+			want: DefaultMarkers,
+		},
+		{
+			name:  "mdx",
+			style: FenceStyleMDX,
+			want:  Markers{Prefix: "{/*", Suffix: "*/}", Begin: "BEGIN_TF_RESOURCE_TABLES", End: "END_TF_RESOURCE_TABLES"},
+		},
+		{
+			name:  "rst with keywords",
+			style: FenceStyleRST,
+			begin: "BEGIN",
+			end:   "END",
+			want:  Markers{Prefix: "..", Begin: "BEGIN", End: "END"},
+		},
+		{
+			name:    "unknown style",
+			style:   "latex",
+			wantErr: true,
+		},
+		{
+			name:    "same keywords",
+			begin:   "TABLES",
+			end:     "TABLES",
+			wantErr: true,
+		},
+		{
+			name:    "keyword with whitespace",
+			begin:   "BEGIN TABLES",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewMarkers(tc.style, tc.begin, tc.end)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("NewMarkers() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected markers -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+// testReporter logs to the test.
+type testReporter struct {
+	t *testing.T
+}
+
+func (r testReporter) Debugf(msg string, args ...interface{}) { r.t.Logf(msg, args...) }
+func (r testReporter) Infof(msg string, args ...interface{})  { r.t.Logf(msg, args...) }
+func (r testReporter) SetOutput(name string, value string)    { r.t.Logf("output %s=%s", name, value) }
+func (r testReporter) AddStepSummary(markdown string)         { r.t.Logf("summary %s", markdown) }
@@ -0,0 +1,255 @@
+package action
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+)
+
+// ValueFormat controls how attribute values are rendered.
+// The zero value renders values in their default representation.
+type ValueFormat struct {
+	// True and False replace the rendering of boolean values, e.g. ✅ and ❌.
+	True  string `yaml:"true"`
+	False string `yaml:"false"`
+	// ThousandsSeparator is inserted between groups of digits in numbers.
+	ThousandsSeparator string `yaml:"thousands_separator"`
+	// Precision is the number of decimal places numbers are rounded to.
+	// If unset, integers are rendered exactly and fractions in their shortest form.
+	Precision *int `yaml:"precision"`
+	// Multiline controls how strings spanning multiple lines are rendered,
+	// either MultilineBreak (default) or MultilineFirstLine.
+	Multiline string `yaml:"multiline"`
+	// MaxLength truncates strings longer than the given number of characters with an ellipsis.
+	MaxLength int `yaml:"max_length"`
+}
+
+const (
+	// MultilineBreak joins lines with `<br>`, or `<br />` in MDX.
+	MultilineBreak = "br"
+	// MultilineFirstLine truncates the string after the first line.
+	MultilineFirstLine = "first_line"
+)
+
+const ellipsis = "…"
+
+// markdownEscaper escapes the inline Markdown syntax of untrusted strings:
+// code spans, emphasis, strikethrough, links, HTML and table cell delimiters.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"~", `\~`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", "&lt;",
+	">", "&gt;",
+)
+
+// escapeMarkdown escapes the string so that it is rendered verbatim, including
+// a leading `#`.
+func escapeMarkdown(s string) string {
+	s = markdownEscaper.Replace(s)
+	if strings.HasPrefix(s, "#") {
+		s = `\` + s
+	}
+
+	return s
+}
+
+// mdxEscaper escapes the braces of strings, which MDX evaluates as expressions.
+var mdxEscaper = strings.NewReplacer("{", `\{`, "}", `\}`)
+
+// lineBreak returns the line break of table cells, self-closing in MDX.
+func lineBreak(mdx bool) string {
+	if mdx {
+		return "<br />"
+	}
+
+	return "<br>"
+}
+
+// escapePipes escapes the unescaped pipes of trusted Markdown, which would
+// otherwise end the table cell. In tables, this includes pipes in code spans.
+func escapePipes(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '|' && !escaped {
+			b.WriteRune('\\')
+		}
+
+		escaped = r == '\\' && !escaped
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func (f ValueFormat) Validate() error {
+	switch f.Multiline {
+	case "", MultilineBreak, MultilineFirstLine:
+	default:
+		return fmt.Errorf("unknown multiline mode %q", f.Multiline)
+	}
+
+	if f.MaxLength < 0 {
+		return fmt.Errorf("max_length must not be negative")
+	}
+
+	return nil
+}
+
+// Format renders the value as the content of a table cell.
+// Strings are escaped so that they are rendered verbatim.
+func (f ValueFormat) Format(value interface{}) string {
+	return f.format(value, false, false)
+}
+
+// format renders the value, leaving strings unescaped if they are trusted
+// Markdown. If mdx is set, the value is rendered as MDX.
+func (f ValueFormat) format(value interface{}, trusted bool, mdx bool) string {
+	switch v := value.(type) {
+	case string:
+		return f.formatString(v, trusted, mdx)
+	case nil:
+		return ""
+	case *terraform.UnknownAttributeValue:
+		return "_unknown_"
+	case bool:
+		return f.formatBool(v)
+	case *big.Float:
+		return f.formatNumber(v)
+	case float64:
+		return f.formatNumber(new(big.Float).SetFloat64(v))
+	case int:
+		return f.formatNumber(new(big.Float).SetInt64(int64(v)))
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func (f ValueFormat) formatString(s string, trusted bool, mdx bool) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+
+	truncated := false
+	if f.Multiline == MultilineFirstLine && len(lines) > 1 {
+		lines, truncated = lines[:1], true
+	}
+
+	s = strings.Join(lines, "\n")
+	if runes := []rune(s); f.MaxLength > 0 && len(runes) > f.MaxLength {
+		s, truncated = string(runes[:f.MaxLength]), true
+	}
+
+	if trusted {
+		s = escapePipes(s)
+	} else {
+		s = escapeMarkdown(s)
+		if mdx {
+			s = mdxEscaper.Replace(s)
+		}
+	}
+
+	s = strings.ReplaceAll(s, "\n", lineBreak(mdx))
+
+	if truncated {
+		s = strings.TrimRightFunc(s, unicode.IsSpace) + ellipsis
+	}
+
+	return s
+}
+
+func (f ValueFormat) formatBool(v bool) string {
+	if v && f.True != "" {
+		return f.True
+	}
+
+	if !v && f.False != "" {
+		return f.False
+	}
+
+	return strconv.FormatBool(v)
+}
+
+func (f ValueFormat) formatNumber(v *big.Float) string {
+	var s string
+	switch {
+	case f.Precision != nil:
+		s = v.Text('f', *f.Precision)
+	case v.IsInt():
+		s = v.Text('f', 0)
+	default:
+		s = v.Text('f', -1)
+	}
+
+	if f.ThousandsSeparator == "" {
+		return s
+	}
+
+	return groupThousands(s, f.ThousandsSeparator)
+}
+
+// groupThousands inserts sep between groups of three digits in the integer
+// part of the decimal number s.
+func groupThousands(s string, sep string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteRune(digit)
+	}
+
+	if hasFraction {
+		b.WriteString(".")
+		b.WriteString(fraction)
+	}
+
+	return b.String()
+}
+
+// FormatValue renders the value of the given attribute or computed column,
+// applying any value mapping before the resource's format.
+// Mapped values and attributes listed in Markdown are not escaped.
+func (r *TerraformResourceType) FormatValue(key string, value interface{}) string {
+	return r.formatValue(key, value, false)
+}
+
+// formatValue renders the value as FormatValue, or as MDX if mdx is set.
+func (r *TerraformResourceType) formatValue(key string, value interface{}, mdx bool) string {
+	if mapped, ok := r.mappedValue(key, value); ok {
+		return escapePipes(mapped)
+	}
+
+	return r.Format.format(value, r.isMarkdown(key), mdx)
+}
+
+func (r *TerraformResourceType) mappedValue(key string, value interface{}) (string, bool) {
+	mapped, ok := r.Values[key][ValueFormat{}.format(value, true, false)]
+	return mapped, ok
+}
+
+func (r *TerraformResourceType) isMarkdown(key string) bool {
+	for _, k := range r.Markdown {
+		if k == key {
+			return true
+		}
+	}
+
+	return false
+}
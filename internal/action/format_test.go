@@ -0,0 +1,139 @@
+package action
+
+import (
+	"testing"
+)
+
+func TestValueFormat_Format(t *testing.T) {
+	t.Parallel()
+
+	precision := 2
+
+	tests := []struct {
+		name   string
+		format ValueFormat
+		value  interface{}
+		want   string
+	}{
+		{
+			name:   "boolean symbols",
+			format: ValueFormat{True: "✅", False: "❌"},
+			value:  false,
+			want:   "❌",
+		},
+		{
+			name:   "thousands separator",
+			format: ValueFormat{ThousandsSeparator: ","},
+			value:  mustParseBigFloat(t, "-1234567"),
+			want:   "-1,234,567",
+		},
+		{
+			name:   "thousands separator with fraction",
+			format: ValueFormat{ThousandsSeparator: ","},
+			value:  mustParseBigFloat(t, "1234.5"),
+			want:   "1,234.5",
+		},
+		{
+			name:   "short number with thousands separator",
+			format: ValueFormat{ThousandsSeparator: ","},
+			value:  mustParseBigFloat(t, "123"),
+			want:   "123",
+		},
+		{
+			name:  "exact decimal",
+			value: mustParseBigFloat(t, "12345678901234567890.123456789"),
+			want:  "12345678901234567890.123456789",
+		},
+		{
+			name:   "precision",
+			format: ValueFormat{Precision: &precision},
+			value:  mustParseBigFloat(t, "3.14159"),
+			want:   "3.14",
+		},
+		{
+			name:  "escaped string",
+			value: "a | `b` <c>",
+			want:  "a \\| \\`b\\` &lt;c&gt;",
+		},
+		{
+			name:  "escaped inline markdown",
+			value: "# *critical* _x_ ~~y~~ [x](http://example.com)",
+			want:  "\\# \\*critical\\* \\_x\\_ \\~\\~y\\~\\~ \\[x\\](http://example.com)",
+		},
+		{
+			name:  "multiline string",
+			value: "first\r\nsecond\n",
+			want:  "first<br>second<br>",
+		},
+		{
+			name:   "first line",
+			format: ValueFormat{Multiline: MultilineFirstLine},
+			value:  "first\nsecond",
+			want:   "first…",
+		},
+		{
+			name:   "max length",
+			format: ValueFormat{MaxLength: 6},
+			value:  "héllo world",
+			want:   "héllo…",
+		},
+		{
+			name:   "short string with max length",
+			format: ValueFormat{MaxLength: 6},
+			value:  "héllo",
+			want:   "héllo",
+		},
+		{
+			name:  "fraction",
+			value: mustParseBigFloat(t, "0.1"),
+			want:  "0.1",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.format.Format(tc.value); got != tc.want {
+				t.Errorf("Format() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTerraformResourceType_FormatValue(t *testing.T) {
+	t.Parallel()
+
+	resource := &TerraformResourceType{
+		Format:   ValueFormat{True: "Yes"},
+		Markdown: []string{"link"},
+		Values: map[string]map[string]string{
+			"priority": {"P1": "🔴 Critical", "P3": "low | info"},
+			"enabled":  {"false": "Disabled"},
+		},
+	}
+
+	tests := []struct {
+		key   string
+		value interface{}
+		want  string
+	}{
+		{key: "priority", value: "P1", want: "🔴 Critical"},
+		{key: "priority", value: "P2", want: "P2"},
+		{key: "priority", value: "P3", want: "low \\| info"},
+		{key: "enabled", value: false, want: "Disabled"},
+		{key: "enabled", value: true, want: "Yes"},
+		{key: "other", value: "P1", want: "P1"},
+		{key: "link", value: "[a](b) | c", want: "[a](b) \\| c"},
+		{key: "link", value: "`a|b` \\| c", want: "`a\\|b` \\| c"},
+		{key: "other", value: "[a](b) | c", want: "\\[a\\](b) \\| c"},
+	}
+
+	for _, tc := range tests {
+		if got := resource.FormatValue(tc.key, tc.value); got != tc.want {
+			t.Errorf("FormatValue(%q, %v) = %v, want %v", tc.key, tc.value, got, tc.want)
+		}
+	}
+}
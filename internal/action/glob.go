@@ -0,0 +1,191 @@
+package action
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+)
+
+// hasMeta reports whether the pattern contains glob metacharacters.
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// matchPath reports whether the slash-separated name matches the pattern.
+// In addition to the syntax of path.Match, a `**` segment matches any number
+// of segments, including none.
+func matchPath(pattern string, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern, name = pattern[1:], name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// PathFilter selects the files and directories of modules which are documented.
+// Patterns match the trailing segments of slash-separated paths, e.g.
+// `*_test.tf` matches `modules/foo/main_test.tf` and `examples/**` matches
+// `modules/foo/examples/bar/main.tf`.
+type PathFilter struct {
+	// Include lists patterns of the files to load. If empty, all files are loaded.
+	Include []string
+	// Exclude lists patterns of the files and directories to ignore.
+	Exclude []string
+}
+
+// Validate returns an error if a pattern is malformed.
+func (f PathFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		for _, segment := range strings.Split(pattern, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Keep reports whether the file is loaded.
+func (f PathFilter) Keep(filename string) bool {
+	name := slashPath(filename)
+
+	if len(f.Include) > 0 && !matchAny(f.Include, name) {
+		return false
+	}
+
+	return !matchAny(f.Exclude, name)
+}
+
+// excludesDir reports whether the directory and its descendants are ignored.
+func (f PathFilter) excludesDir(dir string) bool {
+	return matchAny(f.Exclude, slashPath(dir))
+}
+
+// slashPath returns the slash-separated path filters match, relative to the
+// current directory if possible.
+func slashPath(name string) string {
+	if filepath.IsAbs(name) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, name); err == nil && !strings.HasPrefix(rel, "..") {
+				name = rel
+			}
+		}
+	}
+
+	return filepath.ToSlash(filepath.Clean(name))
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matchPath("**/"+strings.TrimPrefix(pattern, "/"), name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// globModules returns the sorted Terraform module directories matching the
+// pattern. Hidden and excluded directories, such as `.terraform`, are not
+// searched, and directories are only modules if they contain included files.
+// The filter of each directory is returned by filters.
+func globModules(pattern string, filters func(dir string) (PathFilter, error)) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+
+	// walk from the longest prefix without metacharacters
+	segments := strings.Split(pattern, "/")
+	base := []string{}
+	for _, segment := range segments {
+		if hasMeta(segment) {
+			break
+		}
+
+		base = append(base, segment)
+	}
+
+	root := strings.Join(base, "/")
+	if root == "" {
+		root = "."
+		if strings.HasPrefix(pattern, "/") {
+			root = "/"
+		}
+	}
+
+	recursive := false
+	for _, segment := range segments {
+		recursive = recursive || segment == "**"
+	}
+
+	dirs := []string{}
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		name := filepath.ToSlash(p)
+		if p != filepath.FromSlash(root) && strings.HasPrefix(d.Name(), ".") {
+			return filepath.SkipDir
+		}
+
+		filter, err := filters(p)
+		if err != nil {
+			return err
+		}
+
+		if p != filepath.FromSlash(root) && filter.excludesDir(p) {
+			return filepath.SkipDir
+		}
+
+		modulesFS := terraform.NewFilteredFS(tfconfig.NewOsFs(), filter.Keep)
+		if name != "." && matchPath(pattern, name) && tfconfig.IsModuleDirOnFilesystem(modulesFS, p) {
+			dirs = append(dirs, p)
+		}
+
+		if !recursive && strings.Count(name, "/") >= strings.Count(pattern, "/") && name != "." {
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(dirs)
+
+	return dirs, nil
+}
@@ -0,0 +1,66 @@
+package action
+
+import "testing"
+
+func TestMatchPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "modules/*", name: "modules/foo", want: true},
+		{pattern: "modules/*", name: "modules/foo/bar", want: false},
+		{pattern: "modules/**", name: "modules/foo/bar", want: true},
+		{pattern: "modules/**", name: "modules", want: true},
+		{pattern: "**/examples/*", name: "modules/foo/examples/bar", want: true},
+		{pattern: "**/examples/*", name: "examples/bar", want: true},
+		{pattern: "*_test.tf", name: "main_test.tf", want: true},
+		{pattern: "*_test.tf", name: "modules/main_test.tf", want: false},
+		{pattern: "modules/[ab]", name: "modules/c", want: false},
+	}
+
+	for _, tc := range tests {
+		if got := matchPath(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("matchPath(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPathFilter_Keep(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		filter PathFilter
+		name   string
+		want   bool
+	}{
+		{filter: PathFilter{}, name: "main.tf", want: true},
+		{filter: PathFilter{Exclude: []string{"*_test.tf"}}, name: "modules/foo/main_test.tf", want: false},
+		{filter: PathFilter{Exclude: []string{"*_test.tf"}}, name: "modules/foo/main.tf", want: true},
+		{filter: PathFilter{Exclude: []string{"examples/**"}}, name: "modules/foo/examples/bar/main.tf", want: false},
+		{filter: PathFilter{Exclude: []string{"examples/**"}}, name: "modules/examples.tf", want: true},
+		{filter: PathFilter{Include: []string{"main.tf"}}, name: "modules/foo/main.tf", want: true},
+		{filter: PathFilter{Include: []string{"main.tf"}}, name: "modules/foo/outputs.tf", want: false},
+		{filter: PathFilter{Include: []string{"*.tf"}, Exclude: []string{"*_test.tf"}}, name: "main_test.tf", want: false},
+	}
+
+	for _, tc := range tests {
+		if got := tc.filter.Keep(tc.name); got != tc.want {
+			t.Errorf("%+v.Keep(%q) = %v, want %v", tc.filter, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPathFilter_Validate(t *testing.T) {
+	t.Parallel()
+
+	if err := (PathFilter{Exclude: []string{"examples/**", "*_test.tf"}}).Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := (PathFilter{Include: []string{"modules/[a"}}).Validate(); err == nil {
+		t.Error("expected error for malformed pattern")
+	}
+}
@@ -3,17 +3,157 @@ package action
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
 	"gopkg.in/yaml.v3"
 )
 
 var ErrNoResources = errors.New("no resources defined")
 
 type Inputs struct {
+	// WorkingDirectory is the directory of the module, or a newline-separated
+	// list of directories and glob patterns matching module directories, see WorkingDirectories.
 	WorkingDirectory string
 	ResourceTypes    ResourcesInput
 	OutputFile       string
 	HeaderLevel      int
+	// Mode is either ModeWrite (default) or ModeCheck.
+	Mode string
+	// RequireFence fails if the fences of a section are not found in the output file, instead of appending them.
+	// It is nil if unset, so that configuration files may set it.
+	RequireFence *bool
+	// FenceStyle is the comment style of the fences, one of FenceStyleHTML (default),
+	// FenceStyleMDX or FenceStyleRST.
+	FenceStyle string
+	// FenceBegin and FenceEnd replace the keywords of the fences if not empty.
+	FenceBegin string
+	FenceEnd   string
+	// AnchorHeading writes the tables below the heading, e.g. `## Resources`, instead of between fences.
+	AnchorHeading string
+	// DryRun logs the changes to the output file instead of writing them.
+	DryRun bool
+	// Summary appends the tables to the GitHub Actions job summary.
+	Summary bool
+	// SummaryCollapsed collapses the tables in the job summary in a <details> element.
+	SummaryCollapsed bool
+	// LinkMode is either LinkModeRelative (default) or LinkModePermalink.
+	LinkMode string
+	// LinkRef is the kind of ref permalinks point at, one of LinkRefSHA (default), LinkRefBranch or LinkRefTag.
+	LinkRef string
+	// Concurrency is the maximum number of modules documented concurrently,
+	// DefaultConcurrency if zero.
+	Concurrency int
+	// InventoryFile is the file listing the resources of all modules, relative
+	// to the current directory. If empty, no inventory is written.
+	InventoryFile string
+	// Include and Exclude are newline-separated patterns of the files and
+	// directories of modules to document, see PathFilter.
+	Include string
+	Exclude string
+	// JSONFile is the file the structured form of the resources of all
+	// modules is written to, relative to the current directory, see JSONDocument.
+	JSONFile string
+	// CompareRef is the git ref the documented resources are compared with,
+	// e.g. `origin/main`. If set, the changes are reported.
+	CompareRef string
+}
+
+// OutputPath returns the path of the output file. Relative paths are relative
+// to the working directory, and may point outside of it.
+func (i Inputs) OutputPath() string {
+	if filepath.IsAbs(i.OutputFile) {
+		return i.OutputFile
+	}
+
+	return filepath.Join(i.WorkingDirectory, i.OutputFile)
+}
+
+// requireFence reports whether RequireFence is set and true.
+func (i Inputs) requireFence() bool {
+	return i.RequireFence != nil && *i.RequireFence
+}
+
+// PathFilter returns the filter of the include and exclude patterns.
+func (i Inputs) PathFilter() PathFilter {
+	return PathFilter{
+		Include: lines(i.Include),
+		Exclude: lines(i.Exclude),
+	}
+}
+
+// lines returns the non-empty lines of s, without surrounding whitespace.
+func lines(s string) []string {
+	result := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
+
+// DefaultConcurrency is the default maximum number of modules documented concurrently.
+const DefaultConcurrency = 4
+
+// WorkingDirectories returns the module directories of the working directory
+// input, in order. Glob patterns, which may contain `**` to match any number
+// of directories, are expanded to the matching directories containing
+// Terraform files. The include and exclude patterns of the inputs and of the
+// configuration files of each directory apply while searching.
+func (i Inputs) WorkingDirectories() ([]string, error) {
+	dirs := []string{}
+	seen := map[string]bool{}
+
+	filters := map[string]PathFilter{}
+	filterOf := func(dir string) (PathFilter, error) {
+		if filter, ok := filters[dir]; ok {
+			return filter, nil
+		}
+
+		config, _, err := LoadConfig(dir)
+		if err != nil {
+			return PathFilter{}, fmt.Errorf("failed to load configuration: %w", err)
+		}
+
+		filter := i.WithConfig(config).PathFilter()
+		if err := filter.Validate(); err != nil {
+			return PathFilter{}, err
+		}
+
+		filters[dir] = filter
+
+		return filter, nil
+	}
+
+	for _, line := range lines(i.WorkingDirectory) {
+		matches := []string{line}
+		if hasMeta(line) {
+			var err error
+			if matches, err = globModules(line, filterOf); err != nil {
+				return nil, fmt.Errorf("failed to expand %q: %w", line, err)
+			}
+
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no modules match %q", line)
+			}
+		}
+
+		for _, dir := range matches {
+			if !seen[dir] {
+				seen[dir] = true
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	if len(dirs) == 0 {
+		return []string{i.WorkingDirectory}, nil
+	}
+
+	return dirs, nil
 }
 
 type ResourcesInput string
@@ -45,18 +185,128 @@ func (r TerraformResources) Validate() error {
 }
 
 type TerraformResourceType struct {
-	Name       string   `yaml:"name"`
-	Attributes []string `yaml:"attributes"`
+	Name        string   `yaml:"name"`
+	Attributes  []string `yaml:"attributes"`
+	Columns     []Column `yaml:"columns"`
+	Description bool     `yaml:"description"`
+	// Section is the name of the fenced section of the output file the table is written to.
+	// Resource types with the same section are written to it in order.
+	Section string `yaml:"section"`
+	// Identifier is the column identifying each row, one of IdentifierName (default),
+	// IdentifierAddress or IdentifierNone.
+	Identifier string `yaml:"identifier"`
+	// Meta lists additional columns describing each resource, see MetaColumns.
+	Meta []string `yaml:"meta"`
+	// LinkAttributes links each attribute value to its definition.
+	LinkAttributes bool `yaml:"link_attributes"`
+	// Format controls how the values of attributes and computed columns are rendered.
+	Format ValueFormat `yaml:"format"`
+	// Values maps rendered values to replacements, keyed by attribute name or column title.
+	Values map[string]map[string]string `yaml:"values"`
+	// Markdown lists the attributes and computed columns whose values are
+	// trusted Markdown, which is rendered without escaping.
+	Markdown []string `yaml:"markdown"`
+	// HeaderLevel overrides the level of the header preceding the table.
+	HeaderLevel int `yaml:"header_level"`
+
+	// params are the parameters of the fence configuring the resource type, if any.
+	params string
 }
 
 func (r *TerraformResourceType) Validate() error {
-	if len(r.Attributes) == 0 {
+	if len(r.Attributes) == 0 && len(r.Columns) == 0 {
 		return &NoResourceAttributesError{Name: r.Name}
 	}
 
+	if strings.ContainsAny(r.Section, " \t\n") || strings.Contains(r.Section, "-->") || strings.Contains(r.Section, "=") {
+		return fmt.Errorf("invalid section name %q for resource %q", r.Section, r.Name)
+	}
+
+	if r.HeaderLevel < 0 || r.HeaderLevel > 6 {
+		return fmt.Errorf("invalid header level %d for resource %q", r.HeaderLevel, r.Name)
+	}
+
+	switch r.Identifier {
+	case "", IdentifierName, IdentifierAddress, IdentifierNone:
+	default:
+		return fmt.Errorf("unknown identifier %q for resource %q", r.Identifier, r.Name)
+	}
+
+	for _, meta := range r.Meta {
+		if !isMetaColumn(meta) {
+			return fmt.Errorf("unknown meta column %q for resource %q", meta, r.Name)
+		}
+	}
+
+	if err := r.Format.Validate(); err != nil {
+		return fmt.Errorf("invalid format for resource %q: %w", r.Name, err)
+	}
+
+	for _, column := range r.Columns {
+		if err := column.Validate(); err != nil {
+			return fmt.Errorf("invalid column for resource %q: %w", r.Name, err)
+		}
+	}
+
+	return nil
+}
+
+const (
+	// IdentifierName identifies rows by the resource name.
+	IdentifierName = "name"
+	// IdentifierAddress identifies rows by the resource address, e.g. `observe_monitor.foo`.
+	IdentifierAddress = "address"
+	// IdentifierNone omits the identifier column.
+	IdentifierNone = "none"
+)
+
+const (
+	MetaFile     = "file"
+	MetaModule   = "module"
+	MetaProvider = "provider"
+)
+
+// MetaColumns are the supported meta columns: the file and module directory
+// defining the resource, its provider configuration, and its meta-arguments.
+var MetaColumns = append([]string{MetaFile, MetaModule}, terraform.MetaArguments...)
+
+func isMetaColumn(name string) bool {
+	for _, column := range MetaColumns {
+		if column == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Column is a computed column, whose value is an expression evaluated against
+// the attributes of each resource.
+type Column struct {
+	Title string `yaml:"title"`
+	Expr  string `yaml:"expr"`
+}
+
+func (c *Column) Validate() error {
+	if c.Title == "" {
+		return errors.New("column title must not be empty")
+	}
+
+	if c.Expr == "" {
+		return fmt.Errorf("column %q: expr must not be empty", c.Title)
+	}
+
+	if _, err := c.Expression(); err != nil {
+		return fmt.Errorf("column %q: %w", c.Title, err)
+	}
+
 	return nil
 }
 
+func (c *Column) Expression() (*terraform.Expression, error) {
+	return terraform.ParseExpression(c.Expr)
+}
+
 type NoResourceAttributesError struct {
 	Name string
 }
@@ -1,8 +1,12 @@
 package action
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestResourcesInput_Parse(t *testing.T) {
@@ -29,6 +33,16 @@ func TestResourcesInput_Parse(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:  "computed column",
+			input: "[{name: foo, columns: [{title: Bar, expr: '${bar}'}]}]",
+			want: TerraformResources{
+				{
+					Name:    "foo",
+					Columns: []Column{{Title: "Bar", Expr: "${bar}"}},
+				},
+			},
+		},
 	}
 
 	for _, tc := range tests {
@@ -73,6 +87,59 @@ func TestResources_Validate(t *testing.T) {
 			},
 			valid: false,
 		},
+		{
+			name: "computed columns only",
+			resources: TerraformResources{
+				{
+					Name:    "foo",
+					Columns: []Column{{Title: "Bar", Expr: "${bar}"}},
+				},
+			},
+			valid: true,
+		},
+		{
+			name: "invalid column expression",
+			resources: TerraformResources{
+				{
+					Name:       "foo",
+					Attributes: []string{"bar"},
+					Columns:    []Column{{Title: "Bar", Expr: "${bar"}},
+				},
+			},
+			valid: false,
+		},
+		{
+			name: "invalid multiline mode",
+			resources: TerraformResources{
+				{
+					Name:       "foo",
+					Attributes: []string{"bar"},
+					Format:     ValueFormat{Multiline: "wrap"},
+				},
+			},
+			valid: false,
+		},
+		{
+			name: "invalid header level",
+			resources: TerraformResources{
+				{
+					Name:        "foo",
+					Attributes:  []string{"bar"},
+					HeaderLevel: 7,
+				},
+			},
+			valid: false,
+		},
+		{
+			name: "untitled column",
+			resources: TerraformResources{
+				{
+					Name:    "foo",
+					Columns: []Column{{Expr: "${bar}"}},
+				},
+			},
+			valid: false,
+		},
 		{
 			name: "valid",
 			resources: TerraformResources{
@@ -100,3 +167,132 @@ func TestResources_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestInputs_OutputPath(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		inputs Inputs
+		want   string
+	}{
+		{
+			inputs: Inputs{WorkingDirectory: "module", OutputFile: "README.md"},
+			want:   "module/README.md",
+		},
+		{
+			inputs: Inputs{WorkingDirectory: "module", OutputFile: "docs/resources.md"},
+			want:   "module/docs/resources.md",
+		},
+		{
+			inputs: Inputs{WorkingDirectory: "modules/foo", OutputFile: "../../docs/foo.md"},
+			want:   "docs/foo.md",
+		},
+		{
+			inputs: Inputs{WorkingDirectory: "module", OutputFile: "/docs/foo.md"},
+			want:   "/docs/foo.md",
+		},
+	}
+
+	for _, tc := range tests {
+		if got := tc.inputs.OutputPath(); got != tc.want {
+			t.Errorf("OutputPath() = %v, want %v", got, tc.want)
+		}
+	}
+}
+
+func TestInputs_WorkingDirectories(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	for _, dir := range []string{"modules/a", "modules/b", "modules/b/examples/c", "modules/empty", "modules/.terraform/d", "configured/a", "configured/b", "configured/b/examples/c", "configured/b/examples/d"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if dir == "modules/empty" {
+			continue
+		}
+
+		if err := os.WriteFile(filepath.Join(root, dir, "main.tf"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the configuration of configured/b excludes its examples, and that of
+	// configured/b/examples/d is not loaded as it is excluded
+	configs := map[string]string{
+		".git/HEAD":                                 "ref: refs/heads/main\n",
+		"configured/b/" + ConfigFileName:            "exclude: [examples/**]\n",
+		"configured/b/examples/d/" + ConfigFileName: "exclude: []\n",
+	}
+
+	for path, content := range configs {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, path)), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "single directory",
+			input: filepath.Join(root, "modules/empty"),
+			want:  []string{filepath.Join(root, "modules/empty")},
+		},
+		{
+			name:  "list",
+			input: filepath.Join(root, "modules/b") + "\n\n" + filepath.Join(root, "modules/a") + "\n",
+			want:  []string{filepath.Join(root, "modules/b"), filepath.Join(root, "modules/a")},
+		},
+		{
+			name:  "glob",
+			input: filepath.Join(root, "modules/*"),
+			want:  []string{filepath.Join(root, "modules/a"), filepath.Join(root, "modules/b")},
+		},
+		{
+			name:  "recursive glob",
+			input: filepath.Join(root, "modules/**"),
+			want:  []string{filepath.Join(root, "modules/a"), filepath.Join(root, "modules/b"), filepath.Join(root, "modules/b/examples/c")},
+		},
+		{
+			name:  "duplicates",
+			input: filepath.Join(root, "modules/a") + "\n" + filepath.Join(root, "modules/*"),
+			want:  []string{filepath.Join(root, "modules/a"), filepath.Join(root, "modules/b")},
+		},
+		{
+			name:  "configured exclude",
+			input: filepath.Join(root, "configured/**"),
+			want:  []string{filepath.Join(root, "configured/a"), filepath.Join(root, "configured/b")},
+		},
+		{
+			name:    "no matches",
+			input:   filepath.Join(root, "other/*"),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Inputs{WorkingDirectory: tc.input}.WorkingDirectories()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("WorkingDirectories() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected directories -want +got:\n%s", diff)
+			}
+		})
+	}
+}
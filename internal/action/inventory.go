@@ -0,0 +1,125 @@
+package action
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// inventoryTables merges the resource types documented by the modules by
+// name, so that each table lists the resources of a type across all modules.
+// The configuration of the first module documenting a type applies to its
+// table, with a module column added.
+func inventoryTables(modules []*moduleResult) (TerraformResources, [][]*ResourceRow) {
+	resourceTypes := TerraformResources{}
+	rowsByType := [][]*ResourceRow{}
+	index := map[string]int{}
+
+	for _, module := range modules {
+		for i, resourceType := range module.resourceTypes {
+			j, ok := index[resourceType.Name]
+			if !ok {
+				inventoryType := *resourceType
+				inventoryType.Meta = []string{MetaModule}
+				for _, meta := range resourceType.Meta {
+					if meta != MetaModule {
+						inventoryType.Meta = append(inventoryType.Meta, meta)
+					}
+				}
+
+				j = len(resourceTypes)
+				index[resourceType.Name] = j
+				resourceTypes = append(resourceTypes, &inventoryType)
+				rowsByType = append(rowsByType, []*ResourceRow{})
+			}
+
+			rowsByType[j] = append(rowsByType[j], module.rows[i]...)
+		}
+	}
+
+	return resourceTypes, rowsByType
+}
+
+// runInventory writes the inventory of the resources of all modules to the inventory file.
+func (r *runner) runInventory(ctx context.Context, inputs Inputs, modules []*moduleResult, reporter Reporter) *moduleResult {
+	result := &moduleResult{dir: inputs.InventoryFile, mode: inputs.Mode}
+
+	for _, module := range modules {
+		if module.err != nil && module.rows == nil {
+			result.err = errors.New("not written, as not all modules were documented")
+			return result
+		}
+	}
+
+	result.err = r.writeInventory(ctx, inputs, modules, reporter, result)
+
+	return result
+}
+
+func (r *runner) writeInventory(ctx context.Context, inputs Inputs, modules []*moduleResult, reporter Reporter, result *moduleResult) error {
+	inventoryFile := inputs.InventoryFile
+
+	// the configuration files of the inventory's directory, up to the root
+	// of the repository, set its fences, header level and links
+	config, paths, err := LoadConfig(filepath.Dir(inventoryFile))
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	for _, path := range paths {
+		reporter.Debugf("loaded inventory configuration from %s", path)
+	}
+
+	inputs = inputs.WithConfig(config)
+	inputs.WorkingDirectory = "."
+	inputs.OutputFile = inventoryFile
+
+	markers, err := NewMarkers(inputs.FenceStyle, inputs.FenceBegin, inputs.FenceEnd)
+	if err != nil {
+		return fmt.Errorf("failed to configure fences: %w", err)
+	}
+
+	links, err := sourceLinker(ctx, inputs)
+	if err != nil {
+		return fmt.Errorf("failed to configure links: %w", err)
+	}
+
+	resourceTypes, rowsByType := inventoryTables(modules)
+
+	if inputs.AnchorHeading != "" {
+		inputs.HeaderLevel = nestHeaderLevels(inputs.AnchorHeading, inputs.HeaderLevel, resourceTypes)
+	}
+
+	opts := MarkdownOptions{
+		Links:       links,
+		HeaderLevel: inputs.HeaderLevel,
+		OmitAnchors: true,
+		MDX:         inputs.FenceStyle == FenceStyleMDX,
+	}
+
+	var buffer bytes.Buffer
+	for i, resourceType := range resourceTypes {
+		if err := WriteMarkdown(*resourceType, rowsByType[i], opts, &buffer); err != nil {
+			return fmt.Errorf("failed to write markdown: %w", err)
+		}
+	}
+
+	output, err := readOutputFile(inputs.OutputPath())
+	if err != nil {
+		return err
+	}
+
+	var updated []byte
+	if inputs.AnchorHeading != "" {
+		updated, err = updateUnderHeading(output.existing, inputs.AnchorHeading, buffer.Bytes(), inputs.requireFence())
+	} else {
+		updated, err = updateContent(output.existing, []Section{{Content: buffer.Bytes()}}, markers, inputs.requireFence(), reporter)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update inventory file content: %w", err)
+	}
+
+	return output.update(updated, inputs, reporter, result)
+}
@@ -0,0 +1,126 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestInventoryTables(t *testing.T) {
+	t.Parallel()
+
+	foo1 := &ResourceRow{Type: "foo", Name: "one", Module: "modules/a"}
+	bar1 := &ResourceRow{Type: "bar", Name: "one", Module: "modules/a"}
+	foo2 := &ResourceRow{Type: "foo", Name: "one", Module: "modules/b"}
+
+	modules := []*moduleResult{
+		{
+			dir: "modules/a",
+			resourceTypes: TerraformResources{
+				{Name: "foo", Attributes: []string{"x"}, Meta: []string{MetaFile, MetaModule}},
+				{Name: "bar", Attributes: []string{"y"}},
+			},
+			rows: [][]*ResourceRow{{foo1}, {bar1}},
+		},
+		{
+			dir: "modules/b",
+			resourceTypes: TerraformResources{
+				{Name: "foo", Attributes: []string{"z"}},
+			},
+			rows: [][]*ResourceRow{{foo2}},
+		},
+	}
+
+	gotTypes, gotRows := inventoryTables(modules)
+
+	wantTypes := TerraformResources{
+		{Name: "foo", Attributes: []string{"x"}, Meta: []string{MetaModule, MetaFile}},
+		{Name: "bar", Attributes: []string{"y"}, Meta: []string{MetaModule}},
+	}
+
+	if diff := cmp.Diff(wantTypes, gotTypes, cmp.AllowUnexported(TerraformResourceType{})); diff != "" {
+		t.Errorf("unexpected resource types -want +got:\n%s", diff)
+	}
+
+	wantRows := [][]*ResourceRow{{foo1, foo2}, {bar1}}
+	if diff := cmp.Diff(wantRows, gotRows); diff != "" {
+		t.Errorf("unexpected rows -want +got:\n%s", diff)
+	}
+}
+
+func TestRunner_WriteInventory(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	module := filepath.Join(root, "modules", "a")
+	inventoryFile := filepath.Join(root, "docs", "inventory.md")
+
+	files := map[string]string{
+		filepath.Join(root, ".git", "HEAD"): "ref: refs/heads/main\n",
+		filepath.Join(root, ConfigFileName): `
+header_level: 3
+fence_begin: BEGIN_INVENTORY
+fence_end: END_INVENTORY
+`,
+		inventoryFile: "# Inventory\n\n<!-- BEGIN_INVENTORY -->\nstale\n<!-- END_INVENTORY -->\n\nFooter\n",
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	row := &ResourceRow{
+		Type:       "foo",
+		Name:       "one",
+		Module:     module,
+		Range:      hcl.Range{Filename: filepath.Join(module, "main.tf"), Start: hcl.Pos{Line: 1}},
+		Attributes: map[string]interface{}{"x": "a"},
+	}
+
+	modules := []*moduleResult{
+		{
+			dir:           module,
+			resourceTypes: TerraformResources{{Name: "foo", Attributes: []string{"x"}}},
+			rows:          [][]*ResourceRow{{row}},
+		},
+	}
+
+	inputs := Inputs{InventoryFile: inventoryFile, Mode: ModeWrite}
+	result := &moduleResult{dir: inventoryFile, mode: inputs.Mode}
+
+	if err := (&runner{}).writeInventory(context.Background(), inputs, modules, testReporter{t}, result); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(inventoryFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the module column shows the absolute path of the module, so only the
+	// section, its links and the content around the fences are compared
+	for _, want := range []string{
+		"# Inventory\n\n<!-- BEGIN_INVENTORY -->\n### foo\n",
+		"| [`one`](../modules/a/main.tf#L1) |",
+		"](../modules/a) | a   |\n\n<!-- END_INVENTORY -->\n\nFooter\n",
+	} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("inventory does not contain %q:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(string(got), "stale") {
+		t.Errorf("inventory contains the stale section:\n%s", got)
+	}
+}
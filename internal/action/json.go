@@ -0,0 +1,198 @@
+package action
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+)
+
+const (
+	// ValueKindLiteral is the kind of values known without evaluating the module.
+	ValueKindLiteral = "literal"
+	// ValueKindUnknown is the kind of values which depend on variables, other
+	// resources or functions, reported with the source of their expression.
+	ValueKindUnknown = "unknown"
+	// ValueKindUnset is the kind of attributes which are not set in the resource block.
+	ValueKindUnset = "unset"
+)
+
+// JSONDocument is the structured form of the documented resources, set as the
+// json output and written to the JSON file.
+type JSONDocument struct {
+	Modules []JSONModule `json:"modules"`
+}
+
+// JSONModule lists the resource types documented for a module.
+type JSONModule struct {
+	Dir           string             `json:"dir"`
+	ResourceTypes []JSONResourceType `json:"resource_types"`
+}
+
+// JSONResourceType lists the resources of a documented resource type.
+type JSONResourceType struct {
+	Name    string    `json:"name"`
+	Section string    `json:"section,omitempty"`
+	Rows    []JSONRow `json:"rows"`
+}
+
+// JSONRow is a documented resource, see ResourceRow.
+type JSONRow struct {
+	Address       string               `json:"address"`
+	Type          string               `json:"type"`
+	Name          string               `json:"name"`
+	Module        string               `json:"module"`
+	Provider      string               `json:"provider"`
+	Description   string               `json:"description,omitempty"`
+	Position      JSONPosition         `json:"position"`
+	MetaArguments map[string]string    `json:"meta_arguments,omitempty"`
+	Attributes    map[string]JSONValue `json:"attributes"`
+	Computed      map[string]JSONValue `json:"computed,omitempty"`
+}
+
+// JSONPosition is the lines of a source file, relative to the current directory.
+type JSONPosition struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	EndLine int    `json:"end_line"`
+}
+
+// JSONValue is the resolved value of an attribute or computed column.
+type JSONValue struct {
+	// Kind is ValueKindLiteral, ValueKindUnknown or ValueKindUnset.
+	Kind  string      `json:"kind"`
+	Value interface{} `json:"value,omitempty"`
+	// Source is the source text of the expression of unknown values.
+	Source string `json:"source,omitempty"`
+	// Position is the definition of the attribute, if set.
+	Position *JSONPosition `json:"position,omitempty"`
+}
+
+// jsonDocument converts the rows of the rendered modules to their structured form.
+func jsonDocument(modules []*moduleResult) JSONDocument {
+	doc := JSONDocument{Modules: []JSONModule{}}
+
+	for _, module := range modules {
+		if !module.rendered {
+			continue
+		}
+
+		jsonModule := JSONModule{
+			Dir:           slashPath(module.dir),
+			ResourceTypes: make([]JSONResourceType, len(module.resourceTypes)),
+		}
+
+		for i, resourceType := range module.resourceTypes {
+			rows := make([]JSONRow, len(module.rows[i]))
+			for j, row := range module.rows[i] {
+				rows[j] = jsonRow(row)
+			}
+
+			jsonModule.ResourceTypes[i] = JSONResourceType{
+				Name:    resourceType.Name,
+				Section: resourceType.Section,
+				Rows:    rows,
+			}
+		}
+
+		doc.Modules = append(doc.Modules, jsonModule)
+	}
+
+	return doc
+}
+
+func jsonRow(row *ResourceRow) JSONRow {
+	result := JSONRow{
+		Address:       row.Address(),
+		Type:          row.Type,
+		Name:          row.Name,
+		Module:        slashPath(row.Module),
+		Provider:      row.Provider,
+		Description:   row.Description,
+		Position:      jsonPosition(row.Range),
+		MetaArguments: row.MetaArguments,
+		Attributes:    make(map[string]JSONValue, len(row.Attributes)),
+	}
+
+	for name, value := range row.Attributes {
+		v := jsonValue(value)
+		if rng, ok := row.AttributeRanges[name]; ok {
+			position := jsonPosition(rng)
+			v.Position = &position
+		}
+
+		result.Attributes[name] = v
+	}
+
+	if len(row.Computed) > 0 {
+		result.Computed = make(map[string]JSONValue, len(row.Computed))
+		for title, value := range row.Computed {
+			result.Computed[title] = jsonValue(value)
+		}
+	}
+
+	return result
+}
+
+func jsonPosition(rng hcl.Range) JSONPosition {
+	return JSONPosition{
+		File:    slashPath(rng.Filename),
+		Line:    rng.Start.Line,
+		EndLine: rng.End.Line,
+	}
+}
+
+// jsonValue converts a value returned by the parser or a computed expression.
+func jsonValue(value interface{}) JSONValue {
+	switch v := value.(type) {
+	case nil:
+		return JSONValue{Kind: ValueKindUnset}
+	case *terraform.UnknownAttributeValue:
+		return JSONValue{Kind: ValueKindUnknown, Source: v.Source}
+	case *big.Float:
+		return JSONValue{Kind: ValueKindLiteral, Value: json.Number(v.Text('g', -1))}
+	default:
+		return JSONValue{Kind: ValueKindLiteral, Value: v}
+	}
+}
+
+// marshalJSON encodes the document with a trailing newline.
+func marshalJSON(doc JSONDocument) ([]byte, error) {
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return append(b, '\n'), nil
+}
+
+// runJSON writes the structured form of the resources of all modules to the JSON file.
+func (r *runner) runJSON(inputs Inputs, modules []*moduleResult, reporter Reporter) *moduleResult {
+	result := &moduleResult{dir: inputs.JSONFile, mode: inputs.Mode}
+
+	for _, module := range modules {
+		if module.err != nil && module.rows == nil {
+			result.err = errors.New("not written, as not all modules were documented")
+			return result
+		}
+	}
+
+	b, err := marshalJSON(jsonDocument(modules))
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	output, err := readOutputFile(inputs.JSONFile)
+	if err != nil {
+		result.err = err
+		return result
+	}
+
+	result.err = output.update(b, inputs, reporter, result)
+
+	return result
+}
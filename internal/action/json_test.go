@@ -0,0 +1,112 @@
+package action
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	row := &ResourceRow{
+		Type:          "observe_monitor",
+		Name:          "foo",
+		Module:        "modules/monitors",
+		Provider:      "observe",
+		MetaArguments: map[string]string{"count": "2"},
+		Range:         hcl.Range{Filename: "modules/monitors/main.tf", Start: hcl.Pos{Line: 1}, End: hcl.Pos{Line: 6}},
+		Attributes: map[string]interface{}{
+			"name":      "foo",
+			"freshness": big.NewFloat(1.5),
+			"workspace": &terraform.UnknownAttributeValue{Source: "var.workspace"},
+			"disabled":  nil,
+		},
+		AttributeRanges: map[string]hcl.Range{
+			"name": {Filename: "modules/monitors/main.tf", Start: hcl.Pos{Line: 2}, End: hcl.Pos{Line: 2}},
+		},
+		Computed: map[string]interface{}{"enabled": true},
+	}
+
+	modules := []*moduleResult{
+		{
+			dir:           "modules/monitors",
+			rendered:      true,
+			resourceTypes: TerraformResources{{Name: "observe_monitor", Section: "monitors"}},
+			rows:          [][]*ResourceRow{{row}},
+		},
+		{dir: "modules/broken"},
+	}
+
+	got, err := marshalJSON(jsonDocument(modules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "modules": [
+    {
+      "dir": "modules/monitors",
+      "resource_types": [
+        {
+          "name": "observe_monitor",
+          "section": "monitors",
+          "rows": [
+            {
+              "address": "observe_monitor.foo",
+              "type": "observe_monitor",
+              "name": "foo",
+              "module": "modules/monitors",
+              "provider": "observe",
+              "position": {
+                "file": "modules/monitors/main.tf",
+                "line": 1,
+                "end_line": 6
+              },
+              "meta_arguments": {
+                "count": "2"
+              },
+              "attributes": {
+                "disabled": {
+                  "kind": "unset"
+                },
+                "freshness": {
+                  "kind": "literal",
+                  "value": 1.5
+                },
+                "name": {
+                  "kind": "literal",
+                  "value": "foo",
+                  "position": {
+                    "file": "modules/monitors/main.tf",
+                    "line": 2,
+                    "end_line": 2
+                  }
+                },
+                "workspace": {
+                  "kind": "unknown",
+                  "source": "var.workspace"
+                }
+              },
+              "computed": {
+                "enabled": {
+                  "kind": "literal",
+                  "value": true
+                }
+              }
+            }
+          ]
+        }
+      ]
+    }
+  ]
+}
+`
+
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("unexpected JSON -want +got:\n%s", diff)
+	}
+}
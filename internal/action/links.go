@@ -0,0 +1,253 @@
+package action
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+const (
+	// LinkModeRelative links to source files relative to the rendered document.
+	LinkModeRelative = "relative"
+	// LinkModePermalink links to source files at a ref in the repository's web UI.
+	LinkModePermalink = "permalink"
+)
+
+const (
+	LinkRefSHA    = "sha"
+	LinkRefBranch = "branch"
+	LinkRefTag    = "tag"
+)
+
+// SourceLinker builds links to the source files of resources.
+type SourceLinker struct {
+	// Dir is the directory relative links are relative to.
+	Dir string
+	// BaseURL is the URL of the repository tree at a ref,
+	// e.g. `https://github.com/org/repo/blob/<sha>`.
+	// If empty, links are relative to Dir.
+	BaseURL string
+	// Root is the repository root directory, which paths under BaseURL are relative to.
+	Root string
+}
+
+// Link returns a link to the lines of the given source range.
+func (l SourceLinker) Link(rng hcl.Range) (string, error) {
+	path, err := l.path(rng.Filename)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s#%s", path, lineFragment(rng)), nil
+}
+
+// DirLink returns a link to the given directory.
+func (l SourceLinker) DirLink(dir string) (string, error) {
+	return l.path(dir)
+}
+
+// path returns the relative path or URL of the given file or directory.
+func (l SourceLinker) path(filename string) (string, error) {
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+
+	if l.BaseURL == "" {
+		dir, err := filepath.Abs(l.Dir)
+		if err != nil {
+			return "", err
+		}
+
+		path, err := filepath.Rel(dir, abs)
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.ToSlash(path), nil
+	}
+
+	root, err := filepath.Abs(l.Root)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(path, "..") {
+		return "", fmt.Errorf("source file %s is outside of the repository %s", filename, l.Root)
+	}
+
+	if path == "." {
+		return l.BaseURL, nil
+	}
+
+	segments := strings.Split(filepath.ToSlash(path), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return fmt.Sprintf("%s/%s", l.BaseURL, strings.Join(segments, "/")), nil
+}
+
+// lineFragment returns the URL fragment highlighting the lines of the range.
+func lineFragment(rng hcl.Range) string {
+	if rng.End.Line > rng.Start.Line {
+		return fmt.Sprintf("L%d-L%d", rng.Start.Line, rng.End.Line)
+	}
+
+	return fmt.Sprintf("L%d", rng.Start.Line)
+}
+
+// NewPermalinkLinker returns a SourceLinker linking to the repository containing dir
+// at the given kind of ref. The repository is determined from the GitHub Actions
+// environment, falling back to the local git metadata.
+func NewPermalinkLinker(ctx context.Context, dir string, ref string, getenv func(string) string) (SourceLinker, error) {
+	git := func(args ...string) (string, error) {
+		out, err := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to run git %s: %w", strings.Join(args, " "), err)
+		}
+
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	repoURL, err := repositoryURL(getenv, git)
+	if err != nil {
+		return SourceLinker{}, err
+	}
+
+	name, err := refName(ref, getenv, git)
+	if err != nil {
+		return SourceLinker{}, err
+	}
+
+	// the head branch of a pull request from a fork only exists in the fork
+	if ref == LinkRefBranch && getenv("GITHUB_HEAD_REF") != "" {
+		if headURL, err := headRepositoryURL(getenv("GITHUB_EVENT_PATH")); err != nil {
+			return SourceLinker{}, err
+		} else if headURL != "" {
+			repoURL = headURL
+		}
+	}
+
+	root := getenv("GITHUB_WORKSPACE")
+	if root == "" {
+		if root, err = git("rev-parse", "--show-toplevel"); err != nil {
+			return SourceLinker{}, err
+		}
+	}
+
+	return SourceLinker{
+		BaseURL: fmt.Sprintf("%s/blob/%s", repoURL, name),
+		Root:    root,
+	}, nil
+}
+
+func repositoryURL(getenv func(string) string, git func(...string) (string, error)) (string, error) {
+	server, repository := getenv("GITHUB_SERVER_URL"), getenv("GITHUB_REPOSITORY")
+	if server != "" && repository != "" {
+		return fmt.Sprintf("%s/%s", strings.TrimSuffix(server, "/"), repository), nil
+	}
+
+	remote, err := git("remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+
+	return remoteWebURL(remote)
+}
+
+// headRepositoryURL returns the URL of the head repository of the pull request
+// in the GitHub Actions event payload, or an empty string if not found.
+func headRepositoryURL(eventPath string) (string, error) {
+	if eventPath == "" {
+		return "", nil
+	}
+
+	b, err := os.ReadFile(eventPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read event payload: %w", err)
+	}
+
+	var event struct {
+		PullRequest struct {
+			Head struct {
+				Repo struct {
+					HTMLURL string `json:"html_url"`
+				} `json:"repo"`
+			} `json:"head"`
+		} `json:"pull_request"`
+	}
+
+	if err := json.Unmarshal(b, &event); err != nil {
+		return "", fmt.Errorf("failed to parse event payload: %w", err)
+	}
+
+	return event.PullRequest.Head.Repo.HTMLURL, nil
+}
+
+// remoteWebURL converts a git remote URL to the URL of the repository's web UI.
+func remoteWebURL(remote string) (string, error) {
+	remote = strings.TrimSuffix(remote, ".git")
+
+	// scp-like syntax, e.g. git@github.com:org/repo
+	if !strings.Contains(remote, "://") {
+		if at := strings.Index(remote, "@"); at != -1 {
+			remote = remote[at+1:]
+		}
+
+		host, path, ok := strings.Cut(remote, ":")
+		if !ok {
+			return "", fmt.Errorf("unsupported git remote %q", remote)
+		}
+
+		return fmt.Sprintf("https://%s/%s", host, path), nil
+	}
+
+	u, err := url.Parse(remote)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse git remote: %w", err)
+	}
+
+	return fmt.Sprintf("https://%s%s", u.Hostname(), u.Path), nil
+}
+
+func refName(ref string, getenv func(string) string, git func(...string) (string, error)) (string, error) {
+	switch ref {
+	case "", LinkRefSHA:
+		if sha := getenv("GITHUB_SHA"); sha != "" {
+			return sha, nil
+		}
+
+		return git("rev-parse", "HEAD")
+	case LinkRefBranch:
+		if branch := getenv("GITHUB_HEAD_REF"); branch != "" {
+			return branch, nil
+		}
+
+		if getenv("GITHUB_REF_TYPE") == "branch" {
+			return getenv("GITHUB_REF_NAME"), nil
+		}
+
+		return git("rev-parse", "--abbrev-ref", "HEAD")
+	case LinkRefTag:
+		if getenv("GITHUB_REF_TYPE") == "tag" {
+			return getenv("GITHUB_REF_NAME"), nil
+		}
+
+		return git("describe", "--tags", "--exact-match")
+	default:
+		return "", fmt.Errorf("unknown link ref %q", ref)
+	}
+}
@@ -0,0 +1,181 @@
+package action
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+func mustAbs(t *testing.T, path string) string {
+	t.Helper()
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return abs
+}
+
+func TestSourceLinker_Link(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		linker   SourceLinker
+		filename string
+		endLine  int
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "relative",
+			linker:   SourceLinker{Dir: "module"},
+			filename: "module/main.tf",
+			want:     "main.tf#L3",
+		},
+		{
+			name:     "relative range",
+			linker:   SourceLinker{Dir: "module"},
+			filename: "module/main.tf",
+			endLine:  10,
+			want:     "main.tf#L3-L10",
+		},
+		{
+			name:     "relative parent",
+			linker:   SourceLinker{Dir: "docs"},
+			filename: "module/main.tf",
+			want:     "../module/main.tf#L3",
+		},
+		{
+			name:     "relative to absolute directory",
+			linker:   SourceLinker{Dir: mustAbs(t, "docs")},
+			filename: "module/main.tf",
+			want:     "../module/main.tf#L3",
+		},
+		{
+			name:     "permalink",
+			linker:   SourceLinker{BaseURL: "https://github.com/org/repo/blob/abc", Root: "/repo"},
+			filename: "/repo/module/my file.tf",
+			endLine:  5,
+			want:     "https://github.com/org/repo/blob/abc/module/my%20file.tf#L3-L5",
+		},
+		{
+			name:     "permalink outside repository",
+			linker:   SourceLinker{BaseURL: "https://github.com/org/repo/blob/abc", Root: "/repo"},
+			filename: "/other/main.tf",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tc.linker.Link(hcl.Range{
+				Filename: tc.filename,
+				Start:    hcl.Pos{Line: 3},
+				End:      hcl.Pos{Line: tc.endLine},
+			})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.want {
+				t.Errorf("Link() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewPermalinkLinker(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "org/repo",
+		"GITHUB_SHA":        "abc",
+		"GITHUB_REF_TYPE":   "tag",
+		"GITHUB_REF_NAME":   "v1.0.0",
+		"GITHUB_WORKSPACE":  "/repo",
+	}
+
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "", want: "https://github.com/org/repo/blob/abc"},
+		{ref: LinkRefSHA, want: "https://github.com/org/repo/blob/abc"},
+		{ref: LinkRefTag, want: "https://github.com/org/repo/blob/v1.0.0"},
+	}
+
+	for _, tc := range tests {
+		linker, err := NewPermalinkLinker(context.Background(), ".", tc.ref, func(key string) string { return env[key] })
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if linker.BaseURL != tc.want {
+			t.Errorf("BaseURL = %v, want %v", linker.BaseURL, tc.want)
+		}
+
+		if linker.Root != "/repo" {
+			t.Errorf("Root = %v, want /repo", linker.Root)
+		}
+	}
+}
+
+func TestNewPermalinkLinker_ForkBranch(t *testing.T) {
+	t.Parallel()
+
+	eventPath := filepath.Join(t.TempDir(), "event.json")
+	if err := os.WriteFile(eventPath, []byte(`{"pull_request": {"head": {"repo": {"html_url": "https://github.com/fork/repo"}}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env := map[string]string{
+		"GITHUB_SERVER_URL": "https://github.com",
+		"GITHUB_REPOSITORY": "org/repo",
+		"GITHUB_HEAD_REF":   "feature",
+		"GITHUB_EVENT_PATH": eventPath,
+		"GITHUB_WORKSPACE":  "/repo",
+	}
+
+	linker, err := NewPermalinkLinker(context.Background(), ".", LinkRefBranch, func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "https://github.com/fork/repo/blob/feature"; linker.BaseURL != want {
+		t.Errorf("BaseURL = %v, want %v", linker.BaseURL, want)
+	}
+}
+
+func TestRemoteWebURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"git@github.com:org/repo.git":            "https://github.com/org/repo",
+		"https://github.com/org/repo.git":        "https://github.com/org/repo",
+		"https://token@github.example.com/org/r": "https://github.example.com/org/r",
+		"ssh://git@github.com:22/org/repo.git":   "https://github.com/org/repo",
+		"github.com:org/repo":                    "https://github.com/org/repo",
+	}
+
+	for remote, want := range tests {
+		got, err := remoteWebURL(remote)
+		if err != nil {
+			t.Errorf("remoteWebURL(%q) unexpected error: %v", remote, err)
+			continue
+		}
+
+		if got != want {
+			t.Errorf("remoteWebURL(%q) = %v, want %v", remote, got, want)
+		}
+	}
+}
@@ -2,29 +2,69 @@ package action
 
 import (
 	"fmt"
+	"html"
 	"io"
+	"net/url"
 	"path/filepath"
 	"strings"
 
-	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
 	"github.com/olekukonko/tablewriter"
 )
 
 type ResourceRow struct {
-	Name       string
-	Position   tfconfig.SourcePos
-	Attributes map[string]interface{}
+	Type string
+	Name string
+	// Module is the directory of the module defining the resource.
+	Module string
+	// Provider is the provider configuration of the resource, e.g. `observe.eu`.
+	Provider string
+	// MetaArguments is the source text of the resource's meta-arguments, keyed by name.
+	MetaArguments map[string]string
+	// Range is the source range of the resource block.
+	Range       hcl.Range
+	Description string
+	Attributes  map[string]interface{}
+	// AttributeRanges are the source ranges of the attributes defined in the resource block.
+	AttributeRanges map[string]hcl.Range
+	Computed        map[string]interface{}
 }
 
-func WriteMarkdown(dir string, resource TerraformResourceType, rows []*ResourceRow, headerLevel int, writer io.Writer) error {
+// Address returns the resource address of the row, e.g. `observe_monitor.foo`.
+func (r *ResourceRow) Address() string {
+	return fmt.Sprintf("%s.%s", r.Type, r.Name)
+}
+
+// MarkdownOptions configures how tables are rendered.
+type MarkdownOptions struct {
+	// Links builds the links to the source of each resource.
+	Links SourceLinker
+	// HeaderLevel is the level of the header preceding each table.
+	HeaderLevel int
+	// Addresses are the addresses of all rendered rows. References to these
+	// resources are linked to the anchor of their row.
+	Addresses map[string]bool
+	// OmitAnchors omits the anchor of each row, e.g. when rows of several
+	// modules share addresses.
+	OmitAnchors bool
+	// MDX renders values as MDX, with self-closing line breaks and escaped braces.
+	MDX bool
+}
+
+func WriteMarkdown(resource TerraformResourceType, rows []*ResourceRow, opts MarkdownOptions, writer io.Writer) error {
+	headerLevel := opts.HeaderLevel
+	if resource.HeaderLevel != 0 {
+		headerLevel = resource.HeaderLevel
+	}
+
 	if _, err := writer.Write([]byte(fmt.Sprintf("%s %s\n\n", strings.Repeat("#", headerLevel), resource.Name))); err != nil {
 		return err
 	}
 
 	table := tablewriter.NewWriter(writer)
 
-	table.SetHeader(tableHeaders(resource.Attributes))
+	table.SetHeader(tableHeaders(resource))
 
 	table.SetAutoFormatHeaders(false)
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
@@ -32,7 +72,7 @@ func WriteMarkdown(dir string, resource TerraformResourceType, rows []*ResourceR
 	table.SetAutoWrapText(false)
 
 	for _, row := range rows {
-		r, err := tableRow(dir, resource, row)
+		r, err := tableRow(resource, row, opts)
 		if err != nil {
 			return err
 		}
@@ -44,40 +84,219 @@ func WriteMarkdown(dir string, resource TerraformResourceType, rows []*ResourceR
 	return nil
 }
 
-func tableRow(dir string, resource TerraformResourceType, data *ResourceRow) ([]string, error) {
-	filename, err := filepath.Rel(dir, data.Position.Filename)
-	if err != nil {
-		return nil, err
+func tableRow(resource TerraformResourceType, data *ResourceRow, opts MarkdownOptions) ([]string, error) {
+	row := []string{}
+
+	if resource.Identifier != IdentifierNone {
+		link, err := opts.Links.Link(data.Range)
+		if err != nil {
+			return nil, err
+		}
+
+		identifier := data.Name
+		if resource.Identifier == IdentifierAddress {
+			identifier = data.Address()
+		}
+
+		row = append(row, fmt.Sprintf("[%s](%s)", codeSpan(identifier), link))
+	}
+
+	for _, meta := range resource.Meta {
+		cell, err := metaCell(meta, data, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		row = append(row, cell)
 	}
 
-	row := []string{fmt.Sprintf("[`%s`](%s#L%d)", data.Name, filename, data.Position.Line)}
+	if resource.Description {
+		row = append(row, resource.Format.Format(data.Description))
+	}
 
 	for _, key := range resource.Attributes {
 		value := data.Attributes[key]
-		row = append(row, ValueToMarkdown(value))
+
+		var definition *hcl.Range
+		if rng, ok := data.AttributeRanges[key]; ok && resource.LinkAttributes {
+			definition = &rng
+		}
+
+		cell, err := opts.cell(resource, key, value, definition)
+		if err != nil {
+			return nil, err
+		}
+
+		row = append(row, cell)
+	}
+
+	for _, column := range resource.Columns {
+		value := data.Computed[column.Title]
+
+		cell, err := opts.cell(resource, column.Title, value, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		row = append(row, cell)
+	}
+
+	if len(row) > 0 && !opts.OmitAnchors {
+		row[0] = fmt.Sprintf(`<a id="%s"></a>%s`, data.Address(), row[0])
 	}
 
 	return row, nil
 }
 
-func tableHeaders(attributes []string) []string {
-	headers := []string{"**Name**"}
+func metaCell(meta string, data *ResourceRow, opts MarkdownOptions) (string, error) {
+	switch meta {
+	case MetaFile:
+		return codeSpan(filepath.Base(data.Range.Filename)), nil
+	case MetaModule:
+		link, err := opts.Links.DirLink(data.Module)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("[%s](%s)", codeSpan(filepath.ToSlash(filepath.Clean(data.Module))), link), nil
+	case MetaProvider:
+		return codeSpan(data.Provider), nil
+	default:
+		if src, ok := data.MetaArguments[meta]; ok {
+			return codeSpan(src), nil
+		}
+
+		return "", nil
+	}
+}
 
-	for _, attribute := range attributes {
+// codeSpan renders s as inline code on a single line.
+func codeSpan(s string) string {
+	s = strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+	if s == "" {
+		return ""
+	}
+
+	if strings.Contains(s, "`") {
+		return fmt.Sprintf("`` %s ``", s)
+	}
+
+	return fmt.Sprintf("`%s`", s)
+}
+
+func tableHeaders(resource TerraformResourceType) []string {
+	headers := []string{}
+
+	switch resource.Identifier {
+	case "", IdentifierName:
+		headers = append(headers, "**Name**")
+	case IdentifierAddress:
+		headers = append(headers, "**Address**")
+	}
+
+	for _, meta := range resource.Meta {
+		switch meta {
+		case MetaFile:
+			headers = append(headers, "**File**")
+		case MetaModule:
+			headers = append(headers, "**Module**")
+		case MetaProvider:
+			headers = append(headers, "**Provider**")
+		default:
+			headers = append(headers, fmt.Sprintf("`%s`", meta))
+		}
+	}
+
+	if resource.Description {
+		headers = append(headers, "**Description**")
+	}
+
+	for _, attribute := range resource.Attributes {
 		headers = append(headers, fmt.Sprintf("`%s`", attribute))
 	}
 
+	for _, column := range resource.Columns {
+		headers = append(headers, fmt.Sprintf("**%s**", column.Title))
+	}
+
 	return headers
 }
 
-func ValueToMarkdown(value interface{}) string {
-	if value == nil {
-		return ""
+// cell renders a value, linking URLs and references to other rendered resources.
+// Otherwise, if definition is set, the value links to the attribute's definition.
+func (o MarkdownOptions) cell(resource TerraformResourceType, key string, value interface{}, definition *hcl.Range) (string, error) {
+	if resource.isMarkdown(key) {
+		return resource.formatValue(key, value, o.MDX), nil
 	}
 
-	if _, ok := value.(*terraform.UnknownAttributeValue); ok {
-		return "_unknown_"
+	if _, mapped := resource.mappedValue(key, value); !mapped {
+		switch v := value.(type) {
+		case *terraform.UnknownAttributeValue:
+			if address, ok := referencedAddress(v.Expr); ok && o.Addresses[address] {
+				return fmt.Sprintf("[%s](#%s)", codeSpan(v.Source), address), nil
+			}
+		case string:
+			if u, err := url.Parse(v); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && !strings.ContainsAny(v, " \t\r\n") {
+				return fmt.Sprintf("[%s](%s)", resource.formatValue(key, value, o.MDX), linkEscaper.Replace(u.String())), nil
+			}
+		}
 	}
 
-	return fmt.Sprintf("%v", value)
+	formatted := resource.formatValue(key, value, o.MDX)
+	if definition == nil || formatted == "" {
+		return formatted, nil
+	}
+
+	link, err := o.Links.Link(*definition)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("[%s](%s)", formatted, link), nil
+}
+
+// linkEscaper escapes characters that would end a link destination or table cell.
+var linkEscaper = strings.NewReplacer(
+	"|", "%7C",
+	"(", "%28",
+	")", "%29",
+	"<", "%3C",
+	">", "%3E",
+)
+
+// referencedAddress returns the address of the resource referenced by an
+// expression consisting of a single reference, e.g. `observe_dataset.foo.id`.
+func referencedAddress(expr hcl.Expression) (string, bool) {
+	if expr == nil {
+		return "", false
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(expr)
+	if diags.HasErrors() || len(traversal) < 2 {
+		return "", false
+	}
+
+	name, ok := traversal[1].(hcl.TraverseAttr)
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s.%s", traversal.RootName(), name.Name), true
+}
+
+// SummaryMarkdown renders the tables of a module for the job summary, under a
+// heading naming the module and optionally collapsed in a <details> element.
+func SummaryMarkdown(module string, tables string, collapsed bool) string {
+	module = filepath.ToSlash(filepath.Clean(module))
+
+	if !collapsed {
+		return fmt.Sprintf("## Terraform resources in %s\n\n%s\n", codeSpan(module), tables)
+	}
+
+	// Markdown is not rendered within <summary>
+	return fmt.Sprintf("<details>\n<summary>Terraform resources in <code>%s</code></summary>\n\n%s\n</details>\n", html.EscapeString(module), tables)
+}
+
+func ValueToMarkdown(value interface{}) string {
+	return ValueFormat{}.Format(value)
 }
@@ -1,8 +1,13 @@
 package action
 
 import (
+	"math/big"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
 )
 
@@ -29,6 +34,16 @@ func TestValueToMarkdown(t *testing.T) {
 			value: 1.1,
 			want:  "1.1",
 		},
+		{
+			name:  "large float",
+			value: float64(1000000),
+			want:  "1000000",
+		},
+		{
+			name:  "big integer",
+			value: mustParseBigFloat(t, "12345678901234567890"),
+			want:  "12345678901234567890",
+		},
 		{
 			name:  "bool",
 			value: true,
@@ -52,3 +67,223 @@ func TestValueToMarkdown(t *testing.T) {
 		})
 	}
 }
+
+func mustParseBigFloat(t *testing.T, s string) *big.Float {
+	t.Helper()
+
+	f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+
+	reference, diags := hclsyntax.ParseExpression([]byte("observe_dataset.bar.id"), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	variable, diags := hclsyntax.ParseExpression([]byte("var.dataset"), "main.tf", hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	resource := TerraformResourceType{
+		Name:           "observe_monitor",
+		Attributes:     []string{"dataset", "url", "name"},
+		LinkAttributes: true,
+	}
+
+	rows := []*ResourceRow{
+		{
+			Type:  "observe_monitor",
+			Name:  "foo",
+			Range: hcl.Range{Filename: "module/main.tf", Start: hcl.Pos{Line: 1}, End: hcl.Pos{Line: 4}},
+			Attributes: map[string]interface{}{
+				"dataset": &terraform.UnknownAttributeValue{Expr: reference, Source: "observe_dataset.bar.id"},
+				"url":     "https://example.com/a|b",
+				"name":    "Foo",
+			},
+			AttributeRanges: map[string]hcl.Range{
+				"url":  {Filename: "module/main.tf", Start: hcl.Pos{Line: 2}, End: hcl.Pos{Line: 2}},
+				"name": {Filename: "module/main.tf", Start: hcl.Pos{Line: 3}, End: hcl.Pos{Line: 3}},
+			},
+		},
+		{
+			Type:  "observe_monitor",
+			Name:  "baz",
+			Range: hcl.Range{Filename: "module/main.tf", Start: hcl.Pos{Line: 5}, End: hcl.Pos{Line: 8}},
+			Attributes: map[string]interface{}{
+				"dataset": &terraform.UnknownAttributeValue{Expr: variable, Source: "var.dataset"},
+				"url":     "not a url",
+			},
+		},
+	}
+
+	opts := MarkdownOptions{
+		Links:       SourceLinker{Dir: "module"},
+		HeaderLevel: 2,
+		Addresses:   map[string]bool{"observe_dataset.bar": true},
+	}
+
+	var b strings.Builder
+	if err := WriteMarkdown(resource, rows, opts, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "## observe_monitor\n\n" +
+		"|                        **Name**                        |                    `dataset`                     |                         `url`                         |      `name`       |\n" +
+		"|--------------------------------------------------------|--------------------------------------------------|-------------------------------------------------------|-------------------|\n" +
+		"| <a id=\"observe_monitor.foo\"></a>[`foo`](main.tf#L1-L4) | [`observe_dataset.bar.id`](#observe_dataset.bar) | [https://example.com/a\\|b](https://example.com/a%7Cb) | [Foo](main.tf#L3) |\n" +
+		"| <a id=\"observe_monitor.baz\"></a>[`baz`](main.tf#L5-L8) | _unknown_                                        | not a url                                             |                   |\n"
+
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("unexpected markdown -want +got:\n%s", diff)
+	}
+}
+
+func TestWriteMarkdown_MDX(t *testing.T) {
+	t.Parallel()
+
+	resource := TerraformResourceType{
+		Name:       "observe_monitor",
+		Attributes: []string{"query", "notes"},
+		Markdown:   []string{"notes"},
+	}
+
+	rows := []*ResourceRow{
+		{
+			Type:  "observe_monitor",
+			Name:  "foo",
+			Range: hcl.Range{Filename: "module/main.tf", Start: hcl.Pos{Line: 1}, End: hcl.Pos{Line: 4}},
+			Attributes: map[string]interface{}{
+				"query": "filter {x}\npick_col y",
+				"notes": "**a**\nb",
+			},
+		},
+	}
+
+	opts := MarkdownOptions{
+		Links:       SourceLinker{Dir: "module"},
+		HeaderLevel: 2,
+		MDX:         true,
+	}
+
+	var b strings.Builder
+	if err := WriteMarkdown(resource, rows, opts, &b); err != nil {
+		t.Fatal(err)
+	}
+
+	// braces are escaped and line breaks self-closing, so that the table is valid MDX
+	want := "## observe_monitor\n\n" +
+		"|                        **Name**                        |            `query`            |   `notes`    |\n" +
+		"|--------------------------------------------------------|-------------------------------|--------------|\n" +
+		"| <a id=\"observe_monitor.foo\"></a>[`foo`](main.tf#L1-L4) | filter \\{x\\}<br />pick\\_col y | **a**<br />b |\n"
+
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("unexpected markdown -want +got:\n%s", diff)
+	}
+}
+
+func TestTableRow(t *testing.T) {
+	t.Parallel()
+
+	row := &ResourceRow{
+		Type:     "observe_monitor",
+		Name:     "foo",
+		Module:   "modules/monitors",
+		Provider: "observe.eu",
+		MetaArguments: map[string]string{
+			"for_each": "toset([\n  \"a\",\n  \"b\",\n])",
+		},
+		Range: hcl.Range{Filename: "modules/monitors/main.tf", Start: hcl.Pos{Line: 1}, End: hcl.Pos{Line: 3}},
+		Attributes: map[string]interface{}{
+			"name": "Foo",
+		},
+	}
+
+	opts := MarkdownOptions{
+		Links: SourceLinker{Dir: "docs"},
+	}
+
+	tests := []struct {
+		name     string
+		resource TerraformResourceType
+		want     []string
+	}{
+		{
+			name:     "name",
+			resource: TerraformResourceType{Attributes: []string{"name"}},
+			want: []string{
+				"<a id=\"observe_monitor.foo\"></a>[`foo`](../modules/monitors/main.tf#L1-L3)",
+				"Foo",
+			},
+		},
+		{
+			name:     "address",
+			resource: TerraformResourceType{Identifier: IdentifierAddress, Attributes: []string{"name"}},
+			want: []string{
+				"<a id=\"observe_monitor.foo\"></a>[`observe_monitor.foo`](../modules/monitors/main.tf#L1-L3)",
+				"Foo",
+			},
+		},
+		{
+			name:     "none",
+			resource: TerraformResourceType{Identifier: IdentifierNone, Attributes: []string{"name"}},
+			want: []string{
+				"<a id=\"observe_monitor.foo\"></a>Foo",
+			},
+		},
+		{
+			name: "meta",
+			resource: TerraformResourceType{
+				Identifier: IdentifierNone,
+				Meta:       []string{MetaFile, MetaModule, MetaProvider, "count", "for_each"},
+			},
+			want: []string{
+				"<a id=\"observe_monitor.foo\"></a>`main.tf`",
+				"[`modules/monitors`](../modules/monitors)",
+				"`observe.eu`",
+				"",
+				"`toset([ \"a\", \"b\", ])`",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tableRow(tc.resource, row, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected row -want +got:\n%s", diff)
+			}
+
+			if headers := tableHeaders(tc.resource); len(headers) != len(got) {
+				t.Errorf("got %d headers for %d columns", len(headers), len(got))
+			}
+		})
+	}
+}
+
+func TestSummaryMarkdown(t *testing.T) {
+	t.Parallel()
+
+	if got, want := SummaryMarkdown("./modules/foo", "tables\n", false), "## Terraform resources in `modules/foo`\n\ntables\n\n"; got != want {
+		t.Errorf("SummaryMarkdown() = %q, want %q", got, want)
+	}
+
+	if got, want := SummaryMarkdown(".", "tables\n", true), "<details>\n<summary>Terraform resources in <code>.</code></summary>\n\ntables\n\n</details>\n"; got != want {
+		t.Errorf("SummaryMarkdown() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,343 @@
+package action
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+)
+
+const lockFileName = ".terraform.lock.hcl"
+
+// runner holds the state shared by the modules of a run.
+type runner struct {
+	install sync.Once
+	tfPath  string
+	tfErr   error
+
+	mu sync.Mutex
+	// schemas are the provider schemas of modules, keyed by the hash of their lock file.
+	schemas map[[sha256.Size]byte]*cachedSchemas
+	// loadSchemas replaces terraform init in tests, if set.
+	loadSchemas func(ctx context.Context, dir string) (*tfjson.ProviderSchemas, error)
+
+	// compare checks out the compare ref once, in compareDir, a worktree of
+	// the repository in compareRoot.
+	compare     sync.Once
+	compareRoot string
+	compareDir  string
+	compareErr  error
+}
+
+type cachedSchemas struct {
+	once    sync.Once
+	schemas *tfjson.ProviderSchemas
+	err     error
+}
+
+// moduleResult is the outcome of documenting a module.
+type moduleResult struct {
+	dir  string
+	mode string
+	// markdown is the rendered tables, if rendered is set.
+	markdown string
+	rendered bool
+	// printed reports whether the module has no output file, so that its
+	// tables are printed by a MarkdownPrinter.
+	printed bool
+	// resourceTypes are the documented resource types, and rows their rows.
+	resourceTypes TerraformResources
+	rows          [][]*ResourceRow
+	// changes is the rendered changes since the compare ref, if any.
+	changes string
+	// changed reports whether the output file changed, if compared is set.
+	changed  bool
+	compared bool
+	err      error
+}
+
+// status returns a short description of the outcome.
+func (r *moduleResult) status() string {
+	switch {
+	case errors.Is(r.err, ErrStale):
+		return "❌ out of date"
+	case r.err != nil:
+		return "❌ " + r.err.Error()
+	case !r.compared:
+		return "✅ rendered"
+	case r.mode == ModeCheck || !r.changed:
+		return "✅ up to date"
+	default:
+		return "✅ updated"
+	}
+}
+
+// terraformPath installs Terraform if necessary, once per run.
+func (r *runner) terraformPath(ctx context.Context) (string, error) {
+	r.install.Do(func() {
+		r.tfPath, r.tfErr = terraform.EnsureInstalled(ctx, version.MustConstraints(version.NewConstraint(">= 1")))
+	})
+
+	if r.tfErr != nil {
+		return "", fmt.Errorf("failed to ensure terraform is installed: %w", r.tfErr)
+	}
+
+	return r.tfPath, nil
+}
+
+// providerSchemas returns the provider schemas of the module in dir. Modules
+// with identical lock files share the schemas of the first of them, without
+// being initialized themselves.
+func (r *runner) providerSchemas(ctx context.Context, dir string, reporter Reporter) (*tfjson.ProviderSchemas, error) {
+	lock, err := os.ReadFile(filepath.Join(dir, lockFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return r.loadProviderSchemas(ctx, dir)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	key := sha256.Sum256(lock)
+
+	r.mu.Lock()
+	if r.schemas == nil {
+		r.schemas = map[[sha256.Size]byte]*cachedSchemas{}
+	}
+
+	cached, ok := r.schemas[key]
+	if !ok {
+		cached = &cachedSchemas{}
+		r.schemas[key] = cached
+	} else {
+		reporter.Debugf("sharing provider schemas of a module with the same lock file")
+	}
+	r.mu.Unlock()
+
+	cached.once.Do(func() {
+		cached.schemas, cached.err = r.loadProviderSchemas(ctx, dir)
+	})
+
+	return cached.schemas, cached.err
+}
+
+func (r *runner) loadProviderSchemas(ctx context.Context, dir string) (*tfjson.ProviderSchemas, error) {
+	if r.loadSchemas != nil {
+		return r.loadSchemas(ctx, dir)
+	}
+
+	tfPath, err := r.terraformPath(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tf, err := tfexec.NewTerraform(dir, tfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create terraform exec: %w", err)
+	}
+
+	if err := tf.Init(ctx); err != nil {
+		return nil, fmt.Errorf("failed to terraform init: %w", err)
+	}
+
+	schemas, err := tf.ProvidersSchema(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider schemas: %w", err)
+	}
+
+	return schemas, nil
+}
+
+// runModules documents each module directory, at most inputs.Concurrency at a time.
+func (r *runner) runModules(ctx context.Context, inputs Inputs, dirs []string, reporter Reporter) []*moduleResult {
+	concurrency := inputs.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	results := make([]*moduleResult, len(dirs))
+	semaphore := make(chan struct{}, concurrency)
+
+	var wg sync.WaitGroup
+	for i, dir := range dirs {
+		i, dir := i, dir
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			moduleInputs := inputs
+			moduleInputs.WorkingDirectory = dir
+
+			results[i] = r.runModule(ctx, moduleInputs, prefixReporter{reporter, dir})
+		}()
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// report sets the outputs and job summary aggregating the results of the
+// modules and of the generated inventory and JSON files, if any. It returns
+// an error if the json output cannot be encoded, after setting the others.
+func (r *runner) report(modules, generated []*moduleResult, inputs Inputs, reporter Reporter) error {
+	var markdown, printed strings.Builder
+	rendered := false
+
+	for _, result := range modules {
+		if !result.rendered {
+			continue
+		}
+
+		rendered = true
+
+		module := result.markdown
+		if len(modules) > 1 {
+			module = SummaryMarkdown(result.dir, result.markdown, false)
+		}
+
+		markdown.WriteString(module)
+		if result.printed {
+			printed.WriteString(module)
+		}
+
+		if inputs.Summary {
+			reporter.AddStepSummary(SummaryMarkdown(result.dir, result.markdown, inputs.SummaryCollapsed))
+		}
+	}
+
+	if printer, ok := reporter.(MarkdownPrinter); ok && printed.Len() > 0 {
+		printer.PrintMarkdown(printed.String())
+	}
+
+	if inputs.CompareRef != "" {
+		var changes strings.Builder
+		for _, result := range modules {
+			if !result.rendered {
+				continue
+			}
+
+			module := ""
+			if len(modules) > 1 {
+				module = result.dir
+			}
+
+			summary := ChangesSummaryMarkdown(module, inputs.CompareRef, result.changes)
+			changes.WriteString(summary)
+
+			if inputs.Summary {
+				reporter.AddStepSummary(summary)
+			}
+		}
+
+		if rendered {
+			reporter.SetOutput("changes", changes.String())
+		}
+	}
+
+	var jsonErr error
+	if rendered {
+		reporter.SetOutput("markdown", markdown.String())
+
+		if b, err := marshalJSON(jsonDocument(modules)); err != nil {
+			jsonErr = fmt.Errorf("failed to set json output: %w", err)
+		} else {
+			reporter.SetOutput("json", string(b))
+		}
+	}
+
+	results := append(append([]*moduleResult{}, modules...), generated...)
+
+	compared, changed := false, false
+	for _, result := range results {
+		compared = compared || result.compared
+		changed = changed || result.changed
+	}
+
+	if compared {
+		reporter.SetOutput("changed", strconv.FormatBool(changed))
+
+		if inputs.Mode == ModeCheck {
+			reporter.SetOutput("stale", strconv.FormatBool(changed))
+		}
+	}
+
+	if len(results) == 1 {
+		return jsonErr
+	}
+
+	for _, result := range results {
+		reporter.Infof("%s: %s", result.dir, result.status())
+	}
+
+	if inputs.Summary {
+		reporter.AddStepSummary(modulesSummaryMarkdown(results))
+	}
+
+	return jsonErr
+}
+
+// modulesSummaryMarkdown renders a table of the outcome of each module.
+func modulesSummaryMarkdown(results []*moduleResult) string {
+	var b strings.Builder
+
+	b.WriteString("## Terraform resource tables\n\n| **Module** | **Result** |\n| --- | --- |\n")
+	for _, result := range results {
+		fmt.Fprintf(&b, "| %s | %s |\n", codeSpan(filepath.ToSlash(filepath.Clean(result.dir))), ValueToMarkdown(result.status()))
+	}
+
+	return b.String()
+}
+
+// modulesError returns an error summarizing the modules which failed, wrapping
+// ErrStale if all of them are out of date.
+func modulesError(results []*moduleResult) error {
+	var failed []string
+	stale := true
+
+	for _, result := range results {
+		if result.err != nil {
+			failed = append(failed, result.dir)
+			stale = stale && errors.Is(result.err, ErrStale)
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	if stale {
+		return fmt.Errorf("%w: %s", ErrStale, strings.Join(failed, ", "))
+	}
+
+	return fmt.Errorf("%d of %d modules failed: %s", len(failed), len(results), strings.Join(failed, ", "))
+}
+
+// prefixReporter prefixes log messages with the module directory.
+type prefixReporter struct {
+	Reporter
+	dir string
+}
+
+func (r prefixReporter) Debugf(msg string, args ...interface{}) {
+	r.Reporter.Debugf("%s: "+msg, append([]interface{}{r.dir}, args...)...)
+}
+
+func (r prefixReporter) Infof(msg string, args ...interface{}) {
+	r.Reporter.Infof("%s: "+msg, append([]interface{}{r.dir}, args...)...)
+}
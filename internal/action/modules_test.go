@@ -0,0 +1,260 @@
+package action
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestModulesSummaryMarkdown(t *testing.T) {
+	t.Parallel()
+
+	results := []*moduleResult{
+		{dir: "modules/a", mode: ModeWrite, rendered: true, compared: true, changed: true},
+		{dir: "modules/b", mode: ModeWrite, rendered: true, compared: true},
+		{dir: "modules/c", mode: ModeCheck, rendered: true, compared: true, changed: true, err: ErrStale},
+		{dir: "modules/d", err: errors.New("failed to load module: a|b")},
+	}
+
+	want := "## Terraform resource tables\n\n" +
+		"| **Module** | **Result** |\n" +
+		"| --- | --- |\n" +
+		"| `modules/a` | ✅ updated |\n" +
+		"| `modules/b` | ✅ up to date |\n" +
+		"| `modules/c` | ❌ out of date |\n" +
+		"| `modules/d` | ❌ failed to load module: a\\|b |\n"
+
+	if diff := cmp.Diff(want, modulesSummaryMarkdown(results)); diff != "" {
+		t.Errorf("unexpected summary -want +got:\n%s", diff)
+	}
+}
+
+func TestModulesError(t *testing.T) {
+	t.Parallel()
+
+	if err := modulesError([]*moduleResult{{dir: "a"}, {dir: "b"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	stale := modulesError([]*moduleResult{{dir: "a", err: ErrStale}, {dir: "b"}})
+	if !errors.Is(stale, ErrStale) {
+		t.Errorf("expected ErrStale, got %v", stale)
+	}
+
+	failed := modulesError([]*moduleResult{{dir: "a", err: ErrStale}, {dir: "b", err: errors.New("boom")}})
+	if failed == nil || errors.Is(failed, ErrStale) {
+		t.Errorf("expected a failure other than ErrStale, got %v", failed)
+	}
+}
+
+// recordingReporter records the outputs, step summaries and printed tables it is given.
+type recordingReporter struct {
+	testReporter
+	outputs   map[string]string
+	summaries []string
+	printed   []string
+}
+
+func newRecordingReporter(t *testing.T) *recordingReporter {
+	return &recordingReporter{testReporter: testReporter{t}, outputs: map[string]string{}}
+}
+
+func (r *recordingReporter) SetOutput(name string, value string) { r.outputs[name] = value }
+func (r *recordingReporter) AddStepSummary(markdown string) {
+	r.summaries = append(r.summaries, markdown)
+}
+
+func (r *recordingReporter) PrintMarkdown(markdown string) {
+	r.printed = append(r.printed, markdown)
+}
+
+func TestRunner_Report_Printed(t *testing.T) {
+	t.Parallel()
+
+	modules := []*moduleResult{
+		{dir: "a", rendered: true, markdown: "a tables\n"},
+		{dir: "b", rendered: true, printed: true, markdown: "b tables\n"},
+		{dir: "c", printed: true, err: errors.New("boom")},
+	}
+
+	reporter := newRecordingReporter(t)
+	if err := (&runner{}).report(modules, nil, Inputs{}, reporter); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{SummaryMarkdown("b", "b tables\n", false)}
+	if diff := cmp.Diff(want, reporter.printed); diff != "" {
+		t.Errorf("unexpected printed tables -want +got:\n%s", diff)
+	}
+}
+
+func TestRunner_Report_Changed(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		mode    string
+		modules []*moduleResult
+		want    map[string]string
+	}{
+		{
+			name:    "not compared",
+			modules: []*moduleResult{{dir: "a", rendered: true, markdown: "tables"}},
+			want:    map[string]string{"markdown": "tables"},
+		},
+		{
+			name: "changed",
+			modules: []*moduleResult{
+				{dir: "a", compared: true},
+				{dir: "b", compared: true, changed: true},
+			},
+			want: map[string]string{"changed": "true"},
+		},
+		{
+			name:    "check up to date",
+			mode:    ModeCheck,
+			modules: []*moduleResult{{dir: "a", compared: true}},
+			want:    map[string]string{"changed": "false", "stale": "false"},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := newRecordingReporter(t)
+			if err := (&runner{}).report(tc.modules, nil, Inputs{Mode: tc.mode}, reporter); err != nil {
+				t.Fatal(err)
+			}
+
+			delete(reporter.outputs, "json")
+			if diff := cmp.Diff(tc.want, reporter.outputs); diff != "" {
+				t.Errorf("unexpected outputs -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunner_RunModules_SharedSchemas(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+
+	lockFiles := map[string]string{
+		"a": "# lock a\n",
+		"b": "# lock a\n",
+		"c": "# lock c\n",
+		"d": "",
+	}
+
+	dirs := make([]string, 0, len(lockFiles))
+	for name, lock := range lockFiles {
+		dir := filepath.Join(root, name)
+		dirs = append(dirs, dir)
+
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		config := `
+terraform {
+	required_providers {
+		test = {
+			source = "test/test"
+		}
+	}
+}
+resource "test_resource" "` + name + `" { name = "` + name + `" }
+`
+		if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if lock != "" {
+			if err := os.WriteFile(filepath.Join(dir, lockFileName), []byte(lock), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := &tfjson.ProviderSchemas{
+		Schemas: map[string]*tfjson.ProviderSchema{
+			"registry.terraform.io/test/test": {
+				ResourceSchemas: map[string]*tfjson.Schema{
+					"test_resource": {
+						Block: &tfjson.SchemaBlock{
+							Attributes: map[string]*tfjson.SchemaAttribute{
+								"name": {AttributeType: cty.String},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	var mu sync.Mutex
+	loads := map[string]int{}
+	active, maxActive := 0, 0
+
+	r := &runner{
+		loadSchemas: func(ctx context.Context, dir string) (*tfjson.ProviderSchemas, error) {
+			mu.Lock()
+			loads[filepath.Base(dir)]++
+			active++
+			if active > maxActive {
+				maxActive = active
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			active--
+			mu.Unlock()
+
+			return schemas, nil
+		},
+	}
+
+	inputs := Inputs{
+		Concurrency:   2,
+		OutputFile:    NoOutputFile,
+		ResourceTypes: "[{name: test_resource, attributes: [name]}]",
+	}
+
+	results := r.runModules(context.Background(), inputs, dirs, testReporter{t})
+
+	for _, result := range results {
+		if result.err != nil || !result.rendered {
+			t.Errorf("%s: unexpected result %v", result.dir, result.err)
+		}
+	}
+
+	// a and b share a lock file, so only one of them loads the schemas
+	if got := loads["a"] + loads["b"]; got != 1 {
+		t.Errorf("unexpected loads %v for the same lock file, want 1", loads)
+	}
+
+	if loads["c"] != 1 || loads["d"] != 1 {
+		t.Errorf("unexpected loads %v, want one for each other module", loads)
+	}
+
+	if maxActive > inputs.Concurrency {
+		t.Errorf("unexpected %d concurrent loads, want at most %d", maxActive, inputs.Concurrency)
+	}
+}
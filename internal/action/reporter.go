@@ -0,0 +1,18 @@
+package action
+
+// Reporter reports the progress and results of a run.
+// *githubactions.Action is a Reporter logging workflow commands.
+type Reporter interface {
+	Debugf(msg string, args ...interface{})
+	Infof(msg string, args ...interface{})
+	// SetOutput sets a named output of the run, e.g. "markdown".
+	SetOutput(name string, value string)
+	// AddStepSummary appends Markdown to the summary of the run.
+	AddStepSummary(markdown string)
+}
+
+// MarkdownPrinter is implemented by reporters which print the tables of the
+// modules without an output file, e.g. on a terminal.
+type MarkdownPrinter interface {
+	PrintMarkdown(markdown string)
+}
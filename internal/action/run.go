@@ -3,49 +3,153 @@ package action
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 
-	"github.com/hashicorp/go-version"
-	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
-	"github.com/sethvargo/go-githubactions"
 )
 
 const (
-	BeforeComment = `<!-- BEGIN_TF_RESOURCE_TABLES -->`
-	AfterComment  = `<!-- END_TF_RESOURCE_TABLES -->`
+	// ModeWrite writes the generated tables to the output file.
+	ModeWrite = "write"
+	// ModeCheck fails if the output file does not contain the generated tables, without writing it.
+	ModeCheck = "check"
 )
 
-func Run(ctx context.Context, inputs Inputs) error {
-	resourceTypes, err := inputs.ResourceTypes.Parse()
+// ErrStale is returned in check mode when the output file is out of date.
+var ErrStale = errors.New("output file is out of date")
+
+// Run documents the modules of the working directory input, see
+// Inputs.WorkingDirectories. Modules are documented concurrently, and
+// the outputs and job summary aggregate their results. If an inventory file
+// or JSON file is set, it lists the resources of all modules.
+func Run(ctx context.Context, inputs Inputs, reporter Reporter) error {
+	dirs, err := inputs.WorkingDirectories()
 	if err != nil {
-		return fmt.Errorf("failed to parse resources: %w", err)
+		return fmt.Errorf("failed to resolve working directories: %w", err)
 	}
 
-	if err := resourceTypes.Validate(); err != nil {
-		return fmt.Errorf("failed to validate resource types input: %w", err)
+	r := &runner{}
+	defer r.removeWorktree(reporter)
+
+	var modules []*moduleResult
+	if len(dirs) == 1 {
+		inputs.WorkingDirectory = dirs[0]
+		modules = []*moduleResult{r.runModule(ctx, inputs, reporter)}
+	} else {
+		modules = r.runModules(ctx, inputs, dirs, reporter)
+	}
+
+	var generated []*moduleResult
+	if inputs.InventoryFile != "" {
+		generated = append(generated, r.runInventory(ctx, inputs, modules, reporter))
+	}
+
+	if inputs.JSONFile != "" {
+		generated = append(generated, r.runJSON(inputs, modules, reporter))
+	}
+
+	if err := r.report(modules, generated, inputs, reporter); err != nil {
+		return err
+	}
+
+	if len(modules) == 1 && len(generated) == 0 {
+		return modules[0].err
 	}
 
-	tfPath, err := terraform.EnsureInstalled(ctx, version.MustConstraints(version.NewConstraint(">= 1")))
+	modules = append(modules, generated...)
+
+	return modulesError(modules)
+}
+
+// runModule documents the module of the working directory.
+func (r *runner) runModule(ctx context.Context, inputs Inputs, reporter Reporter) *moduleResult {
+	result := &moduleResult{dir: inputs.WorkingDirectory, mode: inputs.Mode}
+	result.err = r.documentModule(ctx, inputs, reporter, result)
+
+	return result
+}
+
+// documentModule documents the module of the working directory, recording its outcome in result.
+func (r *runner) documentModule(ctx context.Context, inputs Inputs, reporter Reporter, result *moduleResult) error {
+	switch inputs.Mode {
+	case "", ModeWrite, ModeCheck:
+	default:
+		return fmt.Errorf("unknown mode %q", inputs.Mode)
+	}
+
+	config, paths, err := LoadConfig(inputs.WorkingDirectory)
 	if err != nil {
-		return fmt.Errorf("failed to ensure terraform is installed: %w", err)
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	for _, path := range paths {
+		reporter.Debugf("loaded configuration from %s", path)
+	}
+
+	inputs = inputs.WithConfig(config)
+	result.printed = inputs.OutputFile == ""
+
+	if inputs.Mode == ModeCheck && inputs.OutputFile == "" {
+		return errors.New("check mode requires an output file")
 	}
 
-	tf, err := tfexec.NewTerraform(inputs.WorkingDirectory, tfPath)
+	markers, err := NewMarkers(inputs.FenceStyle, inputs.FenceBegin, inputs.FenceEnd)
 	if err != nil {
-		return fmt.Errorf("failed to create terraform exec: %w", err)
+		return fmt.Errorf("failed to configure fences: %w", err)
 	}
 
-	if err := tf.Init(ctx); err != nil {
-		return fmt.Errorf("failed to terraform init: %w", err)
+	filter := inputs.PathFilter()
+	if err := filter.Validate(); err != nil {
+		return err
 	}
 
-	schemas, err := tf.ProvidersSchema(ctx)
+	if inputs.AnchorHeading != "" {
+		if err := ValidateAnchorHeading(inputs.AnchorHeading); err != nil {
+			return err
+		}
+	}
+
+	resourceTypes, err := inputs.ResourceTypes.Parse()
+	if err != nil {
+		return fmt.Errorf("failed to parse resources: %w", err)
+	}
+
+	resourceTypes = config.resourceTypes(resourceTypes)
+
+	output := &outputFile{path: inputs.OutputPath()}
+	if inputs.OutputFile != "" {
+		if output, err = readOutputFile(inputs.OutputPath()); err != nil {
+			return err
+		}
+
+		reporter.Debugf("found existing content in output file, len=%d", len(output.raw))
+	}
+
+	if inputs.OutputFile != "" && inputs.AnchorHeading == "" {
+		fenced, err := fencedResources(output.existing, markers)
+		if err != nil {
+			return fmt.Errorf("failed to parse fences of %s: %w", inputs.OutputPath(), err)
+		}
+
+		resourceTypes = append(resourceTypes, fenced...)
+	}
+
+	if err := resourceTypes.Validate(); err != nil {
+		return fmt.Errorf("failed to validate resource types input: %w", err)
+	}
+
+	if inputs.AnchorHeading != "" {
+		inputs.HeaderLevel = nestHeaderLevels(inputs.AnchorHeading, inputs.HeaderLevel, resourceTypes)
+	}
+
+	schemas, err := r.providerSchemas(ctx, inputs.WorkingDirectory, reporter)
 	if err != nil {
-		return fmt.Errorf("failed to get provider schemas: %w", err)
+		return err
 	}
 
 	parser, err := terraform.NewParser(schemas)
@@ -53,109 +157,202 @@ func Run(ctx context.Context, inputs Inputs) error {
 		return fmt.Errorf("failed to create parser: %w", err)
 	}
 
+	parser.SetFileFilter(filter.Keep)
+
 	if err := parser.LoadModule(inputs.WorkingDirectory); err != nil {
 		return fmt.Errorf("failed to load module: %w", err)
 	}
 
-	var buffer bytes.Buffer
-	for _, resourceType := range resourceTypes {
-		rows := []*ResourceRow{}
-		for _, resource := range parser.ResourcesOfType(resourceType.Name) {
-			attrs, err := parser.ResourceAttributes(resource, resourceType.Attributes)
-			if err != nil {
-				return fmt.Errorf("failed to parse resource attributes for %s: %w", resource.MapKey(), err)
-			}
+	links, err := sourceLinker(ctx, inputs)
+	if err != nil {
+		return fmt.Errorf("failed to configure links: %w", err)
+	}
 
-			row := &ResourceRow{
-				Name:       resource.Name,
-				Position:   resource.Pos,
-				Attributes: attrs,
-			}
+	opts := MarkdownOptions{
+		Links:       links,
+		HeaderLevel: inputs.HeaderLevel,
+		Addresses:   map[string]bool{},
+		MDX:         inputs.FenceStyle == FenceStyleMDX,
+	}
 
-			rows = append(rows, row)
+	rowsByType := make([][]*ResourceRow, len(resourceTypes))
+	for i, resourceType := range resourceTypes {
+		rows, err := resourceRows(reporter, parser, inputs.WorkingDirectory, resourceType)
+		if err != nil {
+			return err
 		}
 
-		if err := WriteMarkdown(inputs.WorkingDirectory, *resourceType, rows, inputs.HeaderLevel, &buffer); err != nil {
-			return fmt.Errorf("failed to write markdown: %w", err)
+		for _, row := range rows {
+			opts.Addresses[row.Address()] = true
 		}
+
+		rowsByType[i] = rows
 	}
 
-	if os.Getenv("GITHUB_OUTPUT") != "" {
-		githubactions.SetOutput("markdown", buffer.String())
+	result.resourceTypes = resourceTypes
+	result.rows = rowsByType
+
+	if inputs.CompareRef != "" {
+		baseRows, err := r.compareRows(ctx, inputs, schemas, filter, resourceTypes, reporter)
+		if err != nil {
+			return fmt.Errorf("failed to compare with %s: %w", inputs.CompareRef, err)
+		}
+
+		result.changes = changesMarkdown(inputs.CompareRef, diffRows(resourceTypes, baseRows, rowsByType), opts.MDX)
+		reporter.Infof("resource changes since %s:\n%s", inputs.CompareRef, result.changes)
 	}
 
+	var buffer bytes.Buffer
+	sections := []Section{}
+	for i, resourceType := range resourceTypes {
+		var section bytes.Buffer
+		if err := WriteMarkdown(*resourceType, rowsByType[i], opts, io.MultiWriter(&buffer, &section)); err != nil {
+			return fmt.Errorf("failed to write markdown: %w", err)
+		}
+
+		sections = appendSection(sections, Section{
+			Name:    resourceType.Section,
+			Params:  resourceType.params,
+			Content: section.Bytes(),
+		})
+	}
+
+	result.markdown = buffer.String()
+	result.rendered = true
+
 	if inputs.OutputFile == "" {
 		return nil
 	}
 
-	file, err := os.OpenFile(filepath.Join(inputs.WorkingDirectory, inputs.OutputFile), os.O_RDWR|os.O_CREATE, 0644)
+	var updated []byte
+	if inputs.AnchorHeading != "" {
+		updated, err = updateUnderHeading(output.existing, inputs.AnchorHeading, buffer.Bytes(), inputs.requireFence())
+	} else {
+		updated, err = updateContent(output.existing, sections, markers, inputs.requireFence(), reporter)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to open output file: %w", err)
+		return fmt.Errorf("failed to update output file content: %w", err)
 	}
 
-	defer file.Close()
+	return output.update(updated, inputs, reporter, result)
+}
 
-	existing, err := io.ReadAll(file)
-	if err != nil {
-		return fmt.Errorf("failed to read output file: %w", err)
+// appendSection appends the content of section to the section with the same
+// name and parameters, adding the section if necessary.
+func appendSection(sections []Section, section Section) []Section {
+	for i := range sections {
+		if sections[i].Name == section.Name && sections[i].Params == section.Params {
+			sections[i].Content = append(sections[i].Content, section.Content...)
+			return sections
+		}
 	}
 
-	githubactions.Debugf("found existing content in output file, len=%d", len(existing))
+	return append(sections, section)
+}
 
-	if err := file.Truncate(0); err != nil {
-		return fmt.Errorf("failed to truncate output file: %w", err)
-	}
+func sourceLinker(ctx context.Context, inputs Inputs) (SourceLinker, error) {
+	switch inputs.LinkMode {
+	case "", LinkModeRelative:
+		if inputs.OutputFile == "" {
+			return SourceLinker{Dir: inputs.WorkingDirectory}, nil
+		}
 
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek output file: %w", err)
+		return SourceLinker{Dir: filepath.Dir(inputs.OutputPath())}, nil
+	case LinkModePermalink:
+		return NewPermalinkLinker(ctx, inputs.WorkingDirectory, inputs.LinkRef, os.Getenv)
+	default:
+		return SourceLinker{}, fmt.Errorf("unknown link mode %q", inputs.LinkMode)
 	}
+}
+
+// resourceRows builds a row for each documented resource of the given type.
+func resourceRows(reporter Reporter, parser *terraform.Parser, dir string, resourceType *TerraformResourceType) ([]*ResourceRow, error) {
+	attributes := append([]string{}, resourceType.Attributes...)
 
-	newline := []byte("\n")
-	start, end, ok := commentIndexes(existing)
-	if !ok {
-		githubactions.Debugf("comment fences not found, appending to file")
+	columns := make([]*terraform.Expression, len(resourceType.Columns))
+	for i, column := range resourceType.Columns {
+		expr, err := column.Expression()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse column %q: %w", column.Title, err)
+		}
 
-		return writeBytes(
-			file,
-			withTrailingNewline(existing),
-			[]byte(BeforeComment),
-			newline,
-			buffer.Bytes(),
-			newline,
-			[]byte(AfterComment),
-			newline,
-		)
+		columns[i] = expr
+		attributes = append(attributes, expr.Variables()...)
 	}
 
-	githubactions.Debugf("comments found (start = %d, end = %d), updating file", start, end)
+	rows := []*ResourceRow{}
+	for _, resource := range parser.ResourcesOfType(resourceType.Name) {
+		annotations, err := parser.ResourceAnnotations(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource comments for %s: %w", resource.MapKey(), err)
+		}
 
-	return writeBytes(
-		file,
-		existing[:start],
-		[]byte(BeforeComment),
-		newline,
-		buffer.Bytes(),
-		newline,
-		existing[end:],
-	)
-}
+		if annotations.Ignore {
+			reporter.Debugf("ignoring resource %s", resource.MapKey())
+			continue
+		}
 
-func commentIndexes(b []byte) (int, int, bool) {
-	start := bytes.Index(b, []byte(BeforeComment))
-	if start == -1 {
-		return 0, 0, false
-	}
+		attrs, err := parser.ResourceAttributes(resource, attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource attributes for %s: %w", resource.MapKey(), err)
+		}
+
+		rng, diags := parser.ResourceRange(resource)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to find resource block for %s: %w", resource.MapKey(), diags)
+		}
+
+		ranges, err := parser.AttributeRanges(resource, resourceType.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource attributes for %s: %w", resource.MapKey(), err)
+		}
+
+		meta, err := parser.ResourceMetaArguments(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource meta-arguments for %s: %w", resource.MapKey(), err)
+		}
+
+		provider, err := parser.ResourceProvider(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse resource provider for %s: %w", resource.MapKey(), err)
+		}
 
-	end := bytes.Index(b, []byte(AfterComment))
-	if end == -1 {
-		return 0, 0, false
+		computed := make(map[string]interface{}, len(columns))
+		for i, expr := range columns {
+			value, err := expr.Evaluate(attrs)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate column %q for %s: %w", resourceType.Columns[i].Title, resource.MapKey(), err)
+			}
+
+			computed[resourceType.Columns[i].Title] = value
+		}
+
+		row := &ResourceRow{
+			Type:            resource.Type,
+			Name:            resource.Name,
+			Module:          dir,
+			Provider:        providerConfig(provider),
+			MetaArguments:   meta,
+			Range:           rng,
+			Description:     annotations.Description,
+			Attributes:      attrs,
+			AttributeRanges: ranges,
+			Computed:        computed,
+		}
+
+		rows = append(rows, row)
 	}
 
-	if end < start {
-		return 0, 0, false
+	return rows, nil
+}
+
+// providerConfig returns the reference to a provider configuration, e.g. `observe.eu`.
+func providerConfig(provider tfconfig.ProviderRef) string {
+	if provider.Alias == "" {
+		return provider.Name
 	}
 
-	return start, end, true
+	return fmt.Sprintf("%s.%s", provider.Name, provider.Alias)
 }
 
 func writeBytes(w io.Writer, b ...[]byte) error {
@@ -167,11 +364,3 @@ func writeBytes(w io.Writer, b ...[]byte) error {
 
 	return nil
 }
-
-func withTrailingNewline(b []byte) []byte {
-	if len(b) == 0 {
-		return b
-	}
-
-	return bytes.Join([][]byte{b, []byte("\n")}, []byte{})
-}
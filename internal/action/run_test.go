@@ -0,0 +1,22 @@
+package action
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun_CheckWithoutOutputFile(t *testing.T) {
+	t.Parallel()
+
+	inputs := Inputs{
+		WorkingDirectory: t.TempDir(),
+		OutputFile:       NoOutputFile,
+		Mode:             ModeCheck,
+	}
+
+	err := Run(context.Background(), inputs, testReporter{t})
+	if err == nil || !strings.Contains(err.Error(), "requires an output file") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
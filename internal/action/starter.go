@@ -0,0 +1,73 @@
+package action
+
+import (
+	"bytes"
+	"fmt"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/terraform"
+	"gopkg.in/yaml.v3"
+)
+
+// starterResource is a resource type of a starter configuration.
+type starterResource struct {
+	Name       string   `yaml:"name"`
+	Attributes []string `yaml:"attributes"`
+}
+
+// StarterConfig returns a configuration file documenting each managed resource
+// type of the module in dir, with the arguments set in its resource blocks as
+// attributes. It is a starting point to be edited, and does not require
+// provider schemas.
+func StarterConfig(dir string) ([]byte, error) {
+	parser, err := terraform.NewParser(&tfjson.ProviderSchemas{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parser: %w", err)
+	}
+
+	if err := parser.LoadModule(dir); err != nil {
+		return nil, fmt.Errorf("failed to load module: %w", err)
+	}
+
+	resources := []starterResource{}
+	for _, resourceType := range parser.ResourceTypes() {
+		seen := map[string]bool{}
+		resource := starterResource{Name: resourceType, Attributes: []string{}}
+
+		for _, r := range parser.ResourcesOfType(resourceType) {
+			names, err := parser.ResourceArgumentNames(r)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse resource arguments for %s: %w", r.MapKey(), err)
+			}
+
+			for _, name := range names {
+				if !seen[name] {
+					seen[name] = true
+					resource.Attributes = append(resource.Attributes, name)
+				}
+			}
+		}
+
+		resources = append(resources, resource)
+	}
+
+	config := struct {
+		OutputFile string            `yaml:"output_file"`
+		Resources  []starterResource `yaml:"resources"`
+	}{
+		OutputFile: DefaultOutputFile,
+		Resources:  resources,
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s, see https://github.com/observeinc/terraform-resource-markdown-table-action\n", ConfigFileName)
+
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(config); err != nil {
+		return nil, fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package action
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStarterConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	config := `
+resource "observe_monitor" "foo" {
+	count = 1
+	name  = "foo"
+
+	rule {
+		threshold = 1
+	}
+}
+
+resource "observe_monitor" "bar" {
+	name        = "bar"
+	description = "bar"
+}
+
+resource "observe_dataset" "baz" {
+	workspace = "ws"
+}
+`
+
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := StarterConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# .tf-resource-table.yml, see https://github.com/observeinc/terraform-resource-markdown-table-action
+output_file: README.md
+resources:
+  - name: observe_dataset
+    attributes:
+      - workspace
+  - name: observe_monitor
+    attributes:
+      - description
+      - name
+`
+
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("unexpected config -want +got:\n%s", diff)
+	}
+}
@@ -0,0 +1,174 @@
+package action
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/observeinc/terraform-resource-markdown-table-action/internal/diff"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// outputFile is the existing content of an output file.
+type outputFile struct {
+	path string
+	raw  []byte
+	// existing is the decoded content, which is updated.
+	existing []byte
+	encoding textEncoding
+}
+
+// readOutputFile reads the output file, which may not exist.
+func readOutputFile(path string) (*outputFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read output file: %w", err)
+	}
+
+	encoding := detectEncoding(raw)
+
+	return &outputFile{
+		path:     path,
+		raw:      raw,
+		existing: encoding.decode(raw),
+		encoding: encoding,
+	}, nil
+}
+
+// update writes the updated content of the output file, or in check mode and
+// dry runs, logs how it differs from the existing content. Whether the file
+// changed is recorded in result.
+func (f *outputFile) update(updated []byte, inputs Inputs, reporter Reporter, result *moduleResult) error {
+	changed := !bytes.Equal(f.raw, f.encoding.encode(updated))
+	result.compared = true
+	result.changed = changed
+
+	if inputs.Mode == ModeCheck {
+		if !changed {
+			reporter.Infof("%s is up to date", f.path)
+			return nil
+		}
+
+		reporter.Infof("%s", diff.Unified(f.path, f.path, f.existing, updated))
+
+		return fmt.Errorf("%w: %s", ErrStale, f.path)
+	}
+
+	if inputs.DryRun {
+		if !changed {
+			reporter.Infof("dry run: %s would not change", f.path)
+			return nil
+		}
+
+		reporter.Infof("dry run: %s would change:\n%s", f.path, diff.Unified(f.path, f.path, f.existing, updated))
+		return nil
+	}
+
+	if !changed {
+		reporter.Debugf("%s is up to date, not writing it", f.path)
+		return nil
+	}
+
+	return writeFileAtomic(f.path, f.encoding.encode(updated))
+}
+
+// textEncoding is the byte order mark and line endings of a text file, which
+// are preserved when it is rewritten.
+type textEncoding struct {
+	bom  bool
+	crlf bool
+}
+
+// detectEncoding returns the encoding of the file content. Files with any
+// CRLF line endings are considered to use CRLF line endings throughout.
+func detectEncoding(b []byte) textEncoding {
+	return textEncoding{
+		bom:  bytes.HasPrefix(b, utf8BOM),
+		crlf: bytes.Contains(b, []byte("\r\n")),
+	}
+}
+
+// decode returns the content without a byte order mark and with LF line endings.
+func (e textEncoding) decode(b []byte) []byte {
+	b = bytes.TrimPrefix(b, utf8BOM)
+
+	if e.crlf {
+		b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	}
+
+	return b
+}
+
+// encode returns decoded content with the byte order mark and line endings of the encoding.
+func (e textEncoding) encode(b []byte) []byte {
+	if e.crlf {
+		b = bytes.ReplaceAll(b, []byte("\n"), []byte("\r\n"))
+	}
+
+	if e.bom {
+		b = append(append([]byte{}, utf8BOM...), b...)
+	}
+
+	return b
+}
+
+// writeFileAtomic replaces the file with data by renaming a temporary file
+// over it, so that it is never left partially written. The permissions of an
+// existing file are preserved, and symlinks are written through.
+func writeFileAtomic(path string, data []byte) (err error) {
+	mode := fs.FileMode(0644)
+
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("failed to stat output file: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	if err := tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set output file permissions: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace output file: %w", err)
+	}
+
+	return nil
+}
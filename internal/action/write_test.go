@@ -0,0 +1,200 @@
+package action
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTextEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		content     string
+		want        textEncoding
+		wantDecoded string
+	}{
+		{
+			name:        "lf",
+			content:     "a\nb\n",
+			want:        textEncoding{},
+			wantDecoded: "a\nb\n",
+		},
+		{
+			name:        "crlf",
+			content:     "a\r\nb\r\n",
+			want:        textEncoding{crlf: true},
+			wantDecoded: "a\nb\n",
+		},
+		{
+			name:        "bom",
+			content:     "\xef\xbb\xbfa\r\n",
+			want:        textEncoding{bom: true, crlf: true},
+			wantDecoded: "a\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := detectEncoding([]byte(tc.content))
+			if diff := cmp.Diff(tc.want, got, cmp.AllowUnexported(textEncoding{})); diff != "" {
+				t.Errorf("unexpected encoding -want +got:\n%s", diff)
+			}
+
+			decoded := got.decode([]byte(tc.content))
+			if diff := cmp.Diff(tc.wantDecoded, string(decoded)); diff != "" {
+				t.Errorf("unexpected decoded content -want +got:\n%s", diff)
+			}
+
+			if diff := cmp.Diff(tc.content, string(got.encode(decoded))); diff != "" {
+				t.Errorf("unexpected encoded content -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "README.md")
+
+	if err := os.WriteFile(path, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff("new\n", string(content)); diff != "" {
+		t.Errorf("unexpected content -want +got:\n%s", diff)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("unexpected mode, got %v, want %v", mode, os.FileMode(0600))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Errorf("unexpected files left in %s: %v", dir, entries)
+	}
+}
+
+func TestOutputFile_Update(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		inputs      Inputs
+		existing    string
+		updated     string
+		wantChanged bool
+		wantContent string
+		wantStale   bool
+	}{
+		{
+			name:        "write",
+			existing:    "old\n",
+			updated:     "new\n",
+			wantChanged: true,
+			wantContent: "new\n",
+		},
+		{
+			name:        "unchanged",
+			existing:    "same\n",
+			updated:     "same\n",
+			wantContent: "same\n",
+		},
+		{
+			name:        "dry run",
+			inputs:      Inputs{DryRun: true},
+			existing:    "old\n",
+			updated:     "new\n",
+			wantChanged: true,
+			wantContent: "old\n",
+		},
+		{
+			name:        "dry run unchanged",
+			inputs:      Inputs{DryRun: true},
+			existing:    "same\n",
+			updated:     "same\n",
+			wantContent: "same\n",
+		},
+		{
+			name:        "check stale",
+			inputs:      Inputs{Mode: ModeCheck},
+			existing:    "old\n",
+			updated:     "new\n",
+			wantChanged: true,
+			wantContent: "old\n",
+			wantStale:   true,
+		},
+		{
+			name:        "check up to date",
+			inputs:      Inputs{Mode: ModeCheck},
+			existing:    "same\n",
+			updated:     "same\n",
+			wantContent: "same\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "README.md")
+			if err := os.WriteFile(path, []byte(tc.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			output, err := readOutputFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result := &moduleResult{}
+			err = output.update([]byte(tc.updated), tc.inputs, testReporter{t}, result)
+			if errors.Is(err, ErrStale) != tc.wantStale || (err != nil && !tc.wantStale) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !result.compared || result.changed != tc.wantChanged {
+				t.Errorf("compared = %v, changed = %v, want changed = %v", result.compared, result.changed, tc.wantChanged)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.wantContent, string(got)); diff != "" {
+				t.Errorf("unexpected content -want +got:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package diff renders line-based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the unified diff between a and b, labelled with the given
+// file names. It returns an empty string if a and b are equal.
+func Unified(oldName, newName string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+
+	ops := lineOps(splitLines(string(a)), splitLines(string(b)))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, h := range hunks(ops) {
+		oldStart, newStart := h.oldStart, h.newStart
+		if h.oldLines > 0 {
+			oldStart++
+		}
+		if h.newLines > 0 {
+			newStart++
+		}
+
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", oldStart, h.oldLines, newStart, h.newLines)
+
+		for _, o := range h.ops {
+			prefix := " "
+			switch o.kind {
+			case opDelete:
+				prefix = "-"
+			case opInsert:
+				prefix = "+"
+			}
+
+			sb.WriteString(prefix)
+			sb.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// splitLines splits s into lines, keeping line endings.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// maxLCSCells bounds the size of the table of the longest common subsequence.
+// Larger changes, e.g. tables whose columns all widened, are diffed as the
+// deletion of the old lines followed by the insertion of the new ones.
+const maxLCSCells = 1 << 22
+
+// lineOps returns the edit script transforming a into b, based on their
+// longest common subsequence after trimming their common prefix and suffix.
+func lineOps(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	middleA, middleB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(middleA)+1)*(len(middleB)+1) > maxLCSCells {
+		for _, line := range middleA {
+			ops = append(ops, op{opDelete, line})
+		}
+
+		for _, line := range middleB {
+			ops = append(ops, op{opInsert, line})
+		}
+	} else {
+		ops = append(ops, lcsOps(middleA, middleB)...)
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+
+	return ops
+}
+
+// lcsOps returns the edit script transforming a into b, based on their
+// longest common subsequence.
+func lcsOps(a, b []string) []op {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{opEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		ops = append(ops, op{opDelete, a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		ops = append(ops, op{opInsert, b[j]})
+	}
+
+	return ops
+}
+
+type hunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+	ops                []op
+}
+
+// hunks groups changes with up to contextLines of surrounding unchanged lines,
+// merging groups whose context overlaps.
+func hunks(ops []op) []hunk {
+	var result []hunk
+
+	// positions of each op in the old and new files
+	oldPos, newPos := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, o := range ops {
+		oldPos[i+1], newPos[i+1] = oldPos[i], newPos[i]
+		if o.kind != opInsert {
+			oldPos[i+1]++
+		}
+		if o.kind != opDelete {
+			newPos[i+1]++
+		}
+	}
+
+	start, end := -1, -1
+	flush := func() {
+		if start == -1 {
+			return
+		}
+
+		result = append(result, hunk{
+			oldStart: oldPos[start],
+			oldLines: oldPos[end] - oldPos[start],
+			newStart: newPos[start],
+			newLines: newPos[end] - newPos[start],
+			ops:      ops[start:end],
+		})
+	}
+
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+
+		from, to := i-contextLines, i+contextLines+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		if start != -1 && from <= end {
+			end = to
+			continue
+		}
+
+		flush()
+		start, end = from, to
+	}
+
+	flush()
+
+	return result
+}
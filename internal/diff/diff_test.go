@@ -0,0 +1,100 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnified(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want string
+	}{
+		{
+			name: "equal",
+			a:    "a\nb\n",
+			b:    "a\nb\n",
+			want: "",
+		},
+		{
+			name: "new file",
+			a:    "",
+			b:    "a\nb\n",
+			want: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "change with context",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			b:    "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+			want: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "separate hunks",
+			a:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			b:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			want: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name: "merged hunks",
+			a:    "1\n2\n3\n4\n5\n",
+			b:    "one\n2\n3\n4\nfive\n",
+			want: "--- old\n+++ new\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+		{
+			name: "missing trailing newline",
+			a:    "a",
+			b:    "a\n",
+			want: "--- old\n+++ new\n@@ -1,1 +1,1 @@\n-a\n\\ No newline at end of file\n+a\n",
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := Unified("old", "new", []byte(tc.a), []byte(tc.b))
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected diff -want +got:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnified_Large(t *testing.T) {
+	t.Parallel()
+
+	// every line changes, so that the lines are not diffed by their longest
+	// common subsequence, which would need a table of 25M cells
+	var a, b strings.Builder
+	a.WriteString("header\n")
+	b.WriteString("header\n")
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&a, "| %d |\n", i)
+		fmt.Fprintf(&b, "| %d  |\n", i)
+	}
+	a.WriteString("footer\n")
+	b.WriteString("footer\n")
+
+	got := Unified("old", "new", []byte(a.String()), []byte(b.String()))
+
+	if !strings.HasPrefix(got, "--- old\n+++ new\n@@ -1,5002 +1,5002 @@\n header\n-| 0 |\n-| 1 |\n") {
+		t.Errorf("unexpected start of diff:\n%s", got[:100])
+	}
+
+	if !strings.HasSuffix(got, "+| 4999  |\n footer\n") {
+		t.Errorf("unexpected end of diff:\n%s", got[len(got)-100:])
+	}
+
+	if !strings.Contains(got, "-| 4999 |\n+| 0  |\n") {
+		t.Errorf("expected the deletion of the old lines before the insertion of the new ones")
+	}
+}
@@ -0,0 +1,178 @@
+package terraform
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+const (
+	annotationPrefix      = "tfdoc:"
+	annotationIgnore      = "ignore"
+	annotationDescription = "description"
+)
+
+// ResourceAnnotations holds the documentation extracted from the comments
+// directly preceding a resource block.
+type ResourceAnnotations struct {
+	// Ignore is set by a `tfdoc:ignore` comment, and excludes the resource from documentation.
+	Ignore bool
+	// Description is set by a `tfdoc:description` comment, or otherwise by any
+	// other leading comment lines.
+	Description string
+}
+
+// ResourceAnnotations reads the comments attached to the given resource block.
+// Comments are attached when they end on the line immediately before the block
+// and are not separated from each other by blank lines.
+func (p *Parser) ResourceAnnotations(resource *tfconfig.Resource) (*ResourceAnnotations, error) {
+	annotations := &ResourceAnnotations{}
+
+	block, diags := p.ResourceBlock(resource)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	file, diags := p.File(block.DefRange.Filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// JSON configuration has no comments
+	if _, ok := file.Body.(*hclsyntax.Body); !ok {
+		return annotations, nil
+	}
+
+	tokens, diags := p.fileTokens(file, block.DefRange.Filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	lines := leadingComments(tokens, block.DefRange.Start)
+
+	var docs []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, annotationPrefix) {
+			if line != "" {
+				docs = append(docs, line)
+			}
+			continue
+		}
+
+		directive, value, _ := strings.Cut(strings.TrimPrefix(line, annotationPrefix), " ")
+		switch directive {
+		case annotationIgnore:
+			annotations.Ignore = true
+		case annotationDescription:
+			annotations.Description = strings.TrimSpace(value)
+		}
+	}
+
+	if annotations.Description == "" {
+		annotations.Description = strings.Join(docs, " ")
+	}
+
+	return annotations, nil
+}
+
+// fileTokens lexes the file once, caching its tokens for its other resources.
+func (p *Parser) fileTokens(file *hcl.File, filename string) (hclsyntax.Tokens, hcl.Diagnostics) {
+	if tokens, ok := p.tokens[filename]; ok {
+		return tokens, nil
+	}
+
+	tokens, diags := hclsyntax.LexConfig(file.Bytes, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	if p.tokens == nil {
+		p.tokens = map[string]hclsyntax.Tokens{}
+	}
+
+	p.tokens[filename] = tokens
+
+	return tokens, nil
+}
+
+// leadingComments returns the text of the contiguous comments ending on the
+// line before pos, in source order, with comment markers removed.
+func leadingComments(tokens hclsyntax.Tokens, pos hcl.Pos) []string {
+	i := 0
+	for i < len(tokens) && tokens[i].Range.Start.Byte < pos.Byte {
+		i++
+	}
+
+	var comments []hclsyntax.Token
+	expectedLine := pos.Line - 1
+	for i--; i >= 0; i-- {
+		token := tokens[i]
+
+		if token.Type == hclsyntax.TokenNewline {
+			continue
+		}
+
+		if token.Type != hclsyntax.TokenComment || commentEndLine(token) != expectedLine || !startsLine(tokens, i) {
+			break
+		}
+
+		comments = append([]hclsyntax.Token{token}, comments...)
+		expectedLine = token.Range.Start.Line - 1
+	}
+
+	var lines []string
+	for _, comment := range comments {
+		lines = append(lines, commentLines(comment.Bytes)...)
+	}
+
+	return lines
+}
+
+// startsLine reports whether the token at index i is the first on its line,
+// which excludes trailing comments belonging to the preceding line.
+func startsLine(tokens hclsyntax.Tokens, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	previous := tokens[i-1]
+	switch previous.Type {
+	case hclsyntax.TokenNewline:
+		return true
+	case hclsyntax.TokenComment:
+		return bytes.HasSuffix(previous.Bytes, []byte("\n"))
+	default:
+		return false
+	}
+}
+
+// commentEndLine returns the last line containing comment text, since line
+// comments include their trailing newline.
+func commentEndLine(token hclsyntax.Token) int {
+	return token.Range.Start.Line + bytes.Count(bytes.TrimRight(token.Bytes, "\r\n"), []byte("\n"))
+}
+
+func commentLines(b []byte) []string {
+	text := strings.TrimRight(string(b), "\r\n")
+
+	switch {
+	case strings.HasPrefix(text, "#"):
+		text = strings.TrimPrefix(text, "#")
+	case strings.HasPrefix(text, "//"):
+		text = strings.TrimPrefix(text, "//")
+	case strings.HasPrefix(text, "/*"):
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(strings.TrimPrefix(line, "*"))
+		lines = append(lines, line)
+	}
+
+	return lines
+}
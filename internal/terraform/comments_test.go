@@ -0,0 +1,117 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+func TestParser_ResourceAnnotations(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config string
+		want   *ResourceAnnotations
+	}{
+		{
+			name: "no comments",
+			config: `
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{},
+		},
+		{
+			name: "ignore",
+			config: `
+# tfdoc:ignore
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{Ignore: true},
+		},
+		{
+			name: "description directive",
+			config: `
+# Not the description
+# tfdoc:description The description
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{Description: "The description"},
+		},
+		{
+			name: "doc comments",
+			config: `
+// The first line
+// and the second line
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{Description: "The first line and the second line"},
+		},
+		{
+			name: "block comment",
+			config: `
+/*
+ * The description
+ */
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{Description: "The description"},
+		},
+		{
+			name: "detached comment",
+			config: `
+# tfdoc:ignore
+
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{},
+		},
+		{
+			name: "comment for previous block",
+			config: `
+resource "test_resource" "other" {} # tfdoc:ignore
+resource "test_resource" "test" {}
+`,
+			want: &ResourceAnnotations{},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			parser, err := NewParser(&tfjson.ProviderSchemas{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dir, err := os.MkdirTemp("", "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.RemoveAll(dir) })
+
+			if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(tc.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := parser.LoadModule(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := parser.ResourceAnnotations(parser.module.ManagedResources["test_resource.test"])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("unexpected annotations -want +got:\n%s", diff)
+			}
+		})
+	}
+}
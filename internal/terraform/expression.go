@@ -0,0 +1,136 @@
+package terraform
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+)
+
+// expressionFunctions are the functions available to computed expressions,
+// named as in the Terraform language.
+var expressionFunctions = map[string]function.Function{
+	"abs":          stdlib.AbsoluteFunc,
+	"ceil":         stdlib.CeilFunc,
+	"chomp":        stdlib.ChompFunc,
+	"coalesce":     stdlib.CoalesceFunc,
+	"floor":        stdlib.FloorFunc,
+	"format":       stdlib.FormatFunc,
+	"formatdate":   stdlib.FormatDateFunc,
+	"join":         stdlib.JoinFunc,
+	"length":       stdlib.LengthFunc,
+	"lower":        stdlib.LowerFunc,
+	"max":          stdlib.MaxFunc,
+	"min":          stdlib.MinFunc,
+	"regex":        stdlib.RegexFunc,
+	"regexreplace": stdlib.RegexReplaceFunc,
+	"replace":      stdlib.ReplaceFunc,
+	"split":        stdlib.SplitFunc,
+	"substr":       stdlib.SubstrFunc,
+	"title":        stdlib.TitleFunc,
+	"trimspace":    stdlib.TrimSpaceFunc,
+	"upper":        stdlib.UpperFunc,
+}
+
+// Expression is an HCL template evaluated against the attributes of a resource.
+// Attributes are referenced by name, e.g. `${lookback_time / frequency}`.
+type Expression struct {
+	expr hclsyntax.Expression
+	src  string
+}
+
+func ParseExpression(src string) (*Expression, error) {
+	expr, diags := hclsyntax.ParseTemplate([]byte(src), "expr", hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return &Expression{expr: expr, src: src}, nil
+}
+
+// Variables returns the names of the attributes referenced by the expression.
+func (e *Expression) Variables() []string {
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, traversal := range e.expr.Variables() {
+		name := traversal.RootName()
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Evaluate evaluates the expression using the given attribute values, as
+// returned by ResourceAttributes. If any referenced attribute is unknown, the
+// result is an *UnknownAttributeValue. Unset attributes are null, and if the
+// expression cannot use a null value, e.g. in a template, the result is also
+// an *UnknownAttributeValue.
+func (e *Expression) Evaluate(attributes map[string]interface{}) (interface{}, error) {
+	variables := make(map[string]cty.Value, len(attributes))
+	for name, value := range attributes {
+		v, err := ctyValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %q: %w", name, err)
+		}
+
+		variables[name] = v
+	}
+
+	value, diags := e.expr.Value(&hcl.EvalContext{
+		Variables: variables,
+		Functions: expressionFunctions,
+	})
+	if diags.HasErrors() {
+		for _, name := range e.Variables() {
+			if value, ok := attributes[name]; ok && value == nil {
+				return &UnknownAttributeValue{Expr: e.expr, Source: e.src}, nil
+			}
+		}
+
+		return nil, diags
+	}
+
+	if !value.IsWhollyKnown() {
+		return &UnknownAttributeValue{Expr: e.expr, Source: e.src}, nil
+	}
+
+	if value.IsNull() {
+		return nil, nil
+	}
+
+	if !value.Type().IsPrimitiveType() {
+		return nil, fmt.Errorf("expression result is not a primitive type")
+	}
+
+	return primitiveValue(value), nil
+}
+
+func ctyValue(value interface{}) (cty.Value, error) {
+	switch v := value.(type) {
+	case nil:
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	case *UnknownAttributeValue:
+		return cty.DynamicVal, nil
+	case string:
+		return cty.StringVal(v), nil
+	case *big.Float:
+		return cty.NumberVal(v), nil
+	case float64:
+		return cty.NumberFloatVal(v), nil
+	case bool:
+		return cty.BoolVal(v), nil
+	default:
+		return cty.NilVal, fmt.Errorf("unsupported value type %T", value)
+	}
+}
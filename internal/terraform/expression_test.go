@@ -0,0 +1,105 @@
+package terraform
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExpression_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		expr       string
+		attributes map[string]interface{}
+		variables  []string
+		want       interface{}
+		wantErr    bool
+	}{
+		{
+			name:       "arithmetic",
+			expr:       "${lookback_time / frequency}",
+			attributes: map[string]interface{}{"lookback_time": big.NewFloat(300), "frequency": big.NewFloat(60)},
+			variables:  []string{"frequency", "lookback_time"},
+			want:       big.NewFloat(5),
+		},
+		{
+			name:       "concatenation",
+			expr:       "${name} (${priority})",
+			attributes: map[string]interface{}{"name": "foo", "priority": "P1"},
+			variables:  []string{"name", "priority"},
+			want:       "foo (P1)",
+		},
+		{
+			name:       "regex",
+			expr:       `${regex("^[a-z]+", name)}`,
+			attributes: map[string]interface{}{"name": "foo-bar"},
+			variables:  []string{"name"},
+			want:       "foo",
+		},
+		{
+			name:       "unknown attribute",
+			expr:       "${name}-suffix",
+			attributes: map[string]interface{}{"name": &UnknownAttributeValue{}},
+			variables:  []string{"name"},
+			want:       &UnknownAttributeValue{Source: "${name}-suffix"},
+		},
+		{
+			name:       "unset attribute in template",
+			expr:       "${name} (${priority})",
+			attributes: map[string]interface{}{"name": "foo", "priority": nil},
+			variables:  []string{"name", "priority"},
+			want:       &UnknownAttributeValue{Source: "${name} (${priority})"},
+		},
+		{
+			name:       "unset attribute compared with null",
+			expr:       `${priority == null ? "none" : priority}`,
+			attributes: map[string]interface{}{"priority": nil},
+			variables:  []string{"priority"},
+			want:       "none",
+		},
+		{
+			name:       "undefined attribute",
+			expr:       "${name}",
+			attributes: map[string]interface{}{},
+			variables:  []string{"name"},
+			wantErr:    true,
+		},
+		{
+			name:       "non-primitive result",
+			expr:       `${split(",", name)}`,
+			attributes: map[string]interface{}{"name": "a,b"},
+			variables:  []string{"name"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			expr, err := ParseExpression(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(expr.Variables(), tc.variables); diff != "" {
+				t.Errorf("unexpected variables -want +got:\n%s", diff)
+			}
+
+			got, err := expr.Evaluate(tc.attributes)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(UnknownAttributeValue{}, "Expr"), bigFloatComparer); diff != "" {
+				t.Errorf("unexpected value -want +got:\n%s", diff)
+			}
+		})
+	}
+}
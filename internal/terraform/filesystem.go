@@ -0,0 +1,35 @@
+package terraform
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/terraform-config-inspect/tfconfig"
+)
+
+// filteredFS hides the files of directory listings for which keep returns false.
+type filteredFS struct {
+	tfconfig.FS
+	keep func(filename string) bool
+}
+
+// NewFilteredFS returns a filesystem listing only the files of fs for which keep returns true.
+func NewFilteredFS(fs tfconfig.FS, keep func(filename string) bool) tfconfig.FS {
+	return filteredFS{FS: fs, keep: keep}
+}
+
+func (f filteredFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	entries, err := f.FS.ReadDir(dirname)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := entries[:0]
+	for _, entry := range entries {
+		if entry.IsDir() || f.keep(filepath.Join(dirname, entry.Name())) {
+			kept = append(kept, entry)
+		}
+	}
+
+	return kept, nil
+}
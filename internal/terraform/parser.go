@@ -1,12 +1,15 @@
 package terraform
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/terraform-config-inspect/tfconfig"
 	tfjson "github.com/hashicorp/terraform-json"
 	tfaddr "github.com/hashicorp/terraform-registry-address"
@@ -14,11 +17,27 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+// ErrSchemaNotFound is returned for resources whose provider or resource type
+// is not in the provider schemas of the parser.
+var ErrSchemaNotFound = errors.New("schema not found")
+
 type Parser struct {
 	hcl          *hclparse.Parser
 	module       *tfconfig.Module
 	moduleSchema *hcl.BodySchema
 	providers    map[tfaddr.Provider]*schema.ProviderSchema
+	// keep selects the files loaded by LoadModule, if set.
+	keep func(filename string) bool
+	// primaryFiles and overrideFiles are the sorted configuration files of the module.
+	primaryFiles  []string
+	overrideFiles []string
+	// tokens are the lexed tokens of configuration files, keyed by filename.
+	tokens map[string]hclsyntax.Tokens
+	// contents are the decoded resource blocks, keyed by resource address.
+	contents map[string]*hcl.BodyContent
+	// resources are the resource blocks of configuration files, keyed by
+	// filename and resource address, so that each file is decoded once.
+	resources map[string]map[string]*hcl.Block
 }
 
 func NewParser(providers *tfjson.ProviderSchemas) (*Parser, error) {
@@ -41,19 +60,37 @@ func NewParser(providers *tfjson.ProviderSchemas) (*Parser, error) {
 
 type UnknownAttributeValue struct {
 	Expr hcl.Expression
+	// Source is the source text of the expression.
+	Source string
 }
 
 func (v *UnknownAttributeValue) String() string {
 	return "unknown"
 }
 
+// SetFileFilter sets the filter selecting the files of the module loaded by
+// LoadModule. Files for which keep returns false are ignored.
+func (p *Parser) SetFileFilter(keep func(filename string) bool) {
+	p.keep = keep
+}
+
 func (p *Parser) LoadModule(dir string) error {
-	module, diags := tfconfig.LoadModule(dir)
+	var fs tfconfig.FS = tfconfig.NewOsFs()
+	if p.keep != nil {
+		fs = NewFilteredFS(fs, p.keep)
+	}
+
+	module, diags := tfconfig.LoadModuleFromFilesystem(fs, dir)
 	if diags.HasErrors() {
 		return diags.Err()
 	}
 
 	p.module = module
+	p.tokens, p.contents, p.resources = nil, nil, nil
+
+	if err := p.loadFiles(fs, dir); err != nil {
+		return err
+	}
 
 	bs, err := schema.CoreModuleSchemaForVersion(schema.LatestAvailableVersion)
 	if err != nil {
@@ -65,6 +102,45 @@ func (p *Parser) LoadModule(dir string) error {
 	return nil
 }
 
+// loadFiles lists the configuration files of the module in dir, separating
+// override files, which Terraform merges into the blocks of primary files.
+func (p *Parser) loadFiles(fs tfconfig.FS, dir string) error {
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read module directory: %w", err)
+	}
+
+	p.primaryFiles, p.overrideFiles = nil, nil
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || ignoredFile(name) {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".json")
+		if filepath.Ext(base) != ".tf" {
+			continue
+		}
+
+		base = strings.TrimSuffix(base, ".tf")
+		if base == "override" || strings.HasSuffix(base, "_override") {
+			p.overrideFiles = append(p.overrideFiles, filepath.Join(dir, name))
+		} else {
+			p.primaryFiles = append(p.primaryFiles, filepath.Join(dir, name))
+		}
+	}
+
+	sort.Strings(p.primaryFiles)
+	sort.Strings(p.overrideFiles)
+
+	return nil
+}
+
+// ignoredFile reports whether Terraform ignores the file, e.g. editor backups.
+func ignoredFile(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "#") || strings.HasSuffix(name, "~") || strings.HasSuffix(name, "#")
+}
+
 func (p *Parser) File(filename string) (*hcl.File, hcl.Diagnostics) {
 	if filepath.Ext(filename) == ".json" {
 		return p.hcl.ParseJSONFile(filename)
@@ -73,6 +149,16 @@ func (p *Parser) File(filename string) (*hcl.File, hcl.Diagnostics) {
 	}
 }
 
+// Source returns the source text of the given range in a previously parsed file.
+func (p *Parser) Source(rng hcl.Range) string {
+	file, ok := p.hcl.Files()[rng.Filename]
+	if !ok || rng.End.Byte > len(file.Bytes) {
+		return ""
+	}
+
+	return string(rng.SliceBytes(file.Bytes))
+}
+
 func (p *Parser) SetProviderSchema(addr tfaddr.Provider, provider *schema.ProviderSchema) {
 	p.providers[addr] = provider
 }
@@ -112,23 +198,71 @@ func (p *Parser) ResourcesOfType(resourceType string) []*tfconfig.Resource {
 	return resources
 }
 
-func (p *Parser) ResourceAttributes(resource *tfconfig.Resource, attributes []string) (map[string]interface{}, error) {
-	block, diags := p.ResourceBlock(resource)
+// ResourceTypes returns the sorted types of the managed resources defined in the module.
+func (p *Parser) ResourceTypes() []string {
+	seen := map[string]bool{}
+	types := []string{}
+
+	for _, resource := range p.module.ManagedResources {
+		if !seen[resource.Type] {
+			seen[resource.Type] = true
+			types = append(types, resource.Type)
+		}
+	}
+
+	sort.Strings(types)
+
+	return types
+}
+
+// ResourceArgumentNames returns the sorted names of the arguments set in the
+// resource block, excluding meta-arguments and nested blocks.
+func (p *Parser) ResourceArgumentNames(resource *tfconfig.Resource) ([]string, error) {
+	blocks, diags := p.resourceBlocks(resource)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
-	source, err := p.RequiredProviderSource(resource.Provider.Name)
-	if err != nil {
-		return nil, err
+	args := map[string]bool{}
+	for _, block := range blocks {
+		if body, ok := block.Body.(*hclsyntax.Body); ok {
+			for name := range body.Attributes {
+				args[name] = true
+			}
+			continue
+		}
+
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		for name := range attrs {
+			args[name] = true
+		}
 	}
 
-	ps := p.ProviderSchema(source)
-	rs := ps.Resources[resource.Type].ToHCLSchema()
+	meta := map[string]bool{}
+	for _, name := range MetaArguments {
+		meta[name] = true
+	}
 
-	content, _, diags := block.Body.PartialContent(rs)
-	if diags.HasErrors() {
-		return nil, diags
+	names := []string{}
+	for name := range args {
+		if !meta[name] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (p *Parser) ResourceAttributes(resource *tfconfig.Resource, attributes []string) (map[string]interface{}, error) {
+	content, err := p.resourceContent(resource)
+	if err != nil {
+		return nil, err
 	}
 
 	result := make(map[string]interface{}, len(attributes))
@@ -141,7 +275,7 @@ func (p *Parser) ResourceAttributes(resource *tfconfig.Resource, attributes []st
 
 		value, diags := expr.Expr.Value(nil)
 		if diags.HasErrors() {
-			result[attr] = &UnknownAttributeValue{Expr: expr.Expr}
+			result[attr] = &UnknownAttributeValue{Expr: expr.Expr, Source: p.Source(expr.Expr.Range())}
 			continue
 		}
 
@@ -153,44 +287,285 @@ func (p *Parser) ResourceAttributes(resource *tfconfig.Resource, attributes []st
 			return nil, fmt.Errorf("attribute %q for resource %s is not known", attr, resource.MapKey())
 		}
 
-		switch value.Type() {
-		case cty.String:
-			result[attr] = value.AsString()
-		case cty.Number:
-			f, _ := value.AsBigFloat().Float64()
-			result[attr] = f
-		case cty.Bool:
-			result[attr] = value.True()
-		default:
-			panic("unexpected primitive type") // should never happen
+		result[attr] = primitiveValue(value)
+	}
+
+	return result, nil
+}
+
+// AttributeRanges returns the source ranges of the given attributes which are
+// defined in the resource block. The range of an overridden attribute is that
+// of its definition in the override file.
+func (p *Parser) AttributeRanges(resource *tfconfig.Resource, attributes []string) (map[string]hcl.Range, error) {
+	content, err := p.resourceContent(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]hcl.Range, len(attributes))
+	for _, attr := range attributes {
+		if expr, ok := content.Attributes[attr]; ok {
+			result[attr] = expr.Range
 		}
 	}
 
 	return result, nil
 }
 
-func (p *Parser) ResourceBlock(resource *tfconfig.Resource) (*hcl.Block, hcl.Diagnostics) {
-	file, diags := p.File(resource.Pos.Filename)
+// resourceContent decodes the resource block using the resource's provider
+// schema, merging the blocks of override files. The result is cached, as it is
+// used for both the values and the ranges of attributes.
+func (p *Parser) resourceContent(resource *tfconfig.Resource) (*hcl.BodyContent, error) {
+	if content, ok := p.contents[resource.MapKey()]; ok {
+		return content, nil
+	}
+
+	provider, err := p.ResourceProvider(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := p.RequiredProviderSource(provider.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ps := p.ProviderSchema(source)
+	if ps == nil {
+		return nil, fmt.Errorf("provider %s of resource %s: %w", source, resource.MapKey(), ErrSchemaNotFound)
+	}
+
+	bs := ps.Resources[resource.Type]
+	if bs == nil {
+		return nil, fmt.Errorf("resource type %s of provider %s: %w", resource.Type, source, ErrSchemaNotFound)
+	}
+
+	rs := bs.ToHCLSchema()
+
+	content, err := p.mergedContent(resource, rs)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.contents == nil {
+		p.contents = map[string]*hcl.BodyContent{}
+	}
+
+	p.contents[resource.MapKey()] = content
+
+	return content, nil
+}
+
+// mergedContent decodes the resource block and the blocks of override files
+// with the given schema, merging them as Terraform does: each attribute of an
+// override replaces the attribute of the same name, and nested blocks of an
+// override replace all nested blocks of the same type.
+func (p *Parser) mergedContent(resource *tfconfig.Resource, schema *hcl.BodySchema) (*hcl.BodyContent, error) {
+	blocks, diags := p.resourceBlocks(resource)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
-	content, _, diags := file.Body.PartialContent(p.moduleSchema)
+	content, _, diags := blocks[0].Body.PartialContent(schema)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
-	for _, block := range content.Blocks.OfType("resource") {
-		if block.Labels[0] == resource.Type && block.Labels[1] == resource.Name {
-			return block, nil
+	for _, block := range blocks[1:] {
+		override, _, diags := block.Body.PartialContent(schema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		for name, attr := range override.Attributes {
+			content.Attributes[name] = attr
 		}
+
+		if len(override.Blocks) == 0 {
+			continue
+		}
+
+		replaced := map[string]bool{}
+		for _, nested := range override.Blocks {
+			replaced[nested.Type] = true
+		}
+
+		merged := hcl.Blocks{}
+		for _, nested := range content.Blocks {
+			if !replaced[nested.Type] {
+				merged = append(merged, nested)
+			}
+		}
+
+		content.Blocks = append(merged, override.Blocks...)
 	}
 
-	return nil, hcl.Diagnostics{
-		{
-			Severity: hcl.DiagError,
-			Summary:  "resource block not found",
-			Detail:   fmt.Sprintf("resource %s.%s not found", resource.Type, resource.Name),
-		},
+	return content, nil
+}
+
+// primitiveValue converts a known primitive value to its Go equivalent.
+func primitiveValue(value cty.Value) interface{} {
+	switch value.Type() {
+	case cty.String:
+		return value.AsString()
+	case cty.Number:
+		return value.AsBigFloat()
+	case cty.Bool:
+		return value.True()
+	default:
+		panic("unexpected primitive type") // should never happen
+	}
+}
+
+// MetaArguments are the meta-arguments of resource blocks which can be
+// returned by ResourceMetaArguments.
+var MetaArguments = []string{"count", "for_each", "depends_on", "provider"}
+
+// ResourceMetaArguments returns the source text of the meta-arguments defined
+// in the resource block or its overrides, keyed by name.
+func (p *Parser) ResourceMetaArguments(resource *tfconfig.Resource) (map[string]string, error) {
+	schema := &hcl.BodySchema{}
+	for _, name := range MetaArguments {
+		schema.Attributes = append(schema.Attributes, hcl.AttributeSchema{Name: name})
+	}
+
+	content, err := p.mergedContent(resource, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(content.Attributes))
+	for name, attr := range content.Attributes {
+		result[name] = p.Source(attr.Expr.Range())
+	}
+
+	return result, nil
+}
+
+// ResourceProvider returns the provider configuration of the resource, set by
+// its provider meta-argument merged with those of override files, or else the
+// default provider of its type. Unlike the Provider of the resource, it is not
+// reset by override blocks which do not set the meta-argument.
+func (p *Parser) ResourceProvider(resource *tfconfig.Resource) (tfconfig.ProviderRef, error) {
+	schema := &hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "provider"}}}
+
+	content, err := p.mergedContent(resource, schema)
+	if err != nil {
+		return tfconfig.ProviderRef{}, err
+	}
+
+	attr, ok := content.Attributes["provider"]
+	if !ok {
+		name, _, _ := strings.Cut(resource.Type, "_")
+		return tfconfig.ProviderRef{Name: name}, nil
+	}
+
+	traversal, diags := hcl.AbsTraversalForExpr(attr.Expr)
+	if diags.HasErrors() {
+		return tfconfig.ProviderRef{}, diags
 	}
+
+	provider := tfconfig.ProviderRef{Name: traversal.RootName()}
+	if len(traversal) > 1 {
+		if alias, ok := traversal[1].(hcl.TraverseAttr); ok {
+			provider.Alias = alias.Name
+		}
+	}
+
+	return provider, nil
+}
+
+// ResourceRange returns the source range of the whole resource block,
+// from its type keyword to its closing brace.
+func (p *Parser) ResourceRange(resource *tfconfig.Resource) (hcl.Range, hcl.Diagnostics) {
+	block, diags := p.ResourceBlock(resource)
+	if diags.HasErrors() {
+		return hcl.Range{}, diags
+	}
+
+	body, ok := block.Body.(*hclsyntax.Body)
+	if !ok {
+		return block.DefRange, nil
+	}
+
+	return hcl.RangeBetween(block.DefRange, body.SrcRange), nil
+}
+
+// ResourceBlock returns the block defining the resource in a primary
+// configuration file, excluding the blocks of override files.
+func (p *Parser) ResourceBlock(resource *tfconfig.Resource) (*hcl.Block, hcl.Diagnostics) {
+	blocks, diags := p.resourceBlocks(resource)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	return blocks[0], nil
+}
+
+// resourceBlocks returns the block defining the resource in a primary
+// configuration file, followed by its blocks in override files in the order
+// Terraform merges them.
+func (p *Parser) resourceBlocks(resource *tfconfig.Resource) (hcl.Blocks, hcl.Diagnostics) {
+	primaryFiles := p.primaryFiles
+	if len(primaryFiles) == 0 {
+		primaryFiles = []string{resource.Pos.Filename}
+	}
+
+	var blocks hcl.Blocks
+	for _, files := range [][]string{primaryFiles, p.overrideFiles} {
+		for _, filename := range files {
+			block, diags := p.fileResourceBlock(filename, resource)
+			if diags.HasErrors() {
+				return nil, diags
+			}
+
+			if block != nil {
+				blocks = append(blocks, block)
+			}
+		}
+
+		if len(blocks) == 0 {
+			return nil, hcl.Diagnostics{
+				{
+					Severity: hcl.DiagError,
+					Summary:  "resource block not found",
+					Detail:   fmt.Sprintf("resource %s.%s not found", resource.Type, resource.Name),
+				},
+			}
+		}
+	}
+
+	return blocks, nil
+}
+
+// fileResourceBlock returns the block defining the resource in the given file, or nil.
+func (p *Parser) fileResourceBlock(filename string, resource *tfconfig.Resource) (*hcl.Block, hcl.Diagnostics) {
+	blocks, ok := p.resources[filename]
+	if !ok {
+		file, diags := p.File(filename)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		content, _, diags := file.Body.PartialContent(p.moduleSchema)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+
+		blocks = map[string]*hcl.Block{}
+		for _, block := range content.Blocks.OfType("resource") {
+			address := block.Labels[0] + "." + block.Labels[1]
+			if _, ok := blocks[address]; !ok {
+				blocks[address] = block
+			}
+		}
+
+		if p.resources == nil {
+			p.resources = map[string]map[string]*hcl.Block{}
+		}
+
+		p.resources[filename] = blocks
+	}
+
+	return blocks[resource.Type+"."+resource.Name], nil
 }
@@ -1,6 +1,8 @@
 package terraform
 
 import (
+	"errors"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
@@ -12,6 +14,21 @@ import (
 	"github.com/zclconf/go-cty/cty"
 )
 
+var bigFloatComparer = cmp.Comparer(func(a, b *big.Float) bool {
+	return a.Cmp(b) == 0
+})
+
+func mustParseBigFloat(t *testing.T, s string) *big.Float {
+	t.Helper()
+
+	f, _, err := big.ParseFloat(s, 10, 512, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f
+}
+
 func TestParserResourceAttributes(t *testing.T) {
 	t.Parallel()
 
@@ -95,7 +112,44 @@ terraform {
 `,
 			resource: "test_resource.test",
 			want: map[string]interface{}{
-				"foo": float64(2),
+				"foo": big.NewFloat(2),
+			},
+		},
+		{
+			name: "large number attribute",
+			providers: &tfjson.ProviderSchemas{
+				Schemas: map[string]*tfjson.ProviderSchema{
+					"registry.terraform.io/test/test": {
+						ResourceSchemas: map[string]*tfjson.Schema{
+							"test_resource": {
+								Block: &tfjson.SchemaBlock{
+									Attributes: map[string]*tfjson.SchemaAttribute{
+										"foo": {
+											AttributeType: cty.Number,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			config: `
+resource "test_resource" "test" {
+	foo = 12345678901234567890
+}
+
+terraform {
+	required_providers {
+		test = {
+			source = "test/test"
+		}
+	}
+}
+`,
+			resource: "test_resource.test",
+			want: map[string]interface{}{
+				"foo": mustParseBigFloat(t, "12345678901234567890"),
 			},
 		},
 		{
@@ -171,9 +225,55 @@ terraform {
 `,
 			resource: "test_resource.test",
 			want: map[string]interface{}{
-				"foo": &UnknownAttributeValue{},
+				"foo": &UnknownAttributeValue{Source: "var.foo"},
 			},
 		},
+		{
+			name: "provider without schema",
+			providers: &tfjson.ProviderSchemas{
+				Schemas: map[string]*tfjson.ProviderSchema{
+					"registry.terraform.io/test/test": {},
+				},
+			},
+			config: `
+resource "test_resource" "test" {
+	foo = "bar"
+}
+
+terraform {
+	required_providers {
+		test = {
+			source = "oldns/test"
+		}
+	}
+}
+`,
+			resource: "test_resource.test",
+			wantErr:  true,
+		},
+		{
+			name: "resource type without schema",
+			providers: &tfjson.ProviderSchemas{
+				Schemas: map[string]*tfjson.ProviderSchema{
+					"registry.terraform.io/test/test": {},
+				},
+			},
+			config: `
+resource "test_resource" "test" {
+	foo = "bar"
+}
+
+terraform {
+	required_providers {
+		test = {
+			source = "test/test"
+		}
+	}
+}
+`,
+			resource: "test_resource.test",
+			wantErr:  true,
+		},
 	}
 
 	for _, tc := range tests {
@@ -206,7 +306,11 @@ terraform {
 				t.Fatalf("unexpected error: %v", err)
 			}
 
-			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(UnknownAttributeValue{}, "Expr")); diff != "" {
+			if tc.wantErr && !errors.Is(err, ErrSchemaNotFound) {
+				t.Errorf("unexpected error %v, want %v", err, ErrSchemaNotFound)
+			}
+
+			if diff := cmp.Diff(got, tc.want, cmpopts.IgnoreFields(UnknownAttributeValue{}, "Expr"), bigFloatComparer); diff != "" {
 				t.Errorf("unexpected attributes -want +got:\n%s", diff)
 			}
 		})
@@ -342,3 +446,298 @@ resource "test_resource_a" "a" {}
 		})
 	}
 }
+
+func TestParser_SetFileFilter(t *testing.T) {
+	t.Parallel()
+
+	parser, err := NewParser(&tfjson.ProviderSchemas{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf":      `resource "test_resource_a" "main" {}`,
+		"main_test.tf": `resource "test_resource_a" "test" {}`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parser.SetFileFilter(func(filename string) bool {
+		return filepath.Base(filename) != "main_test.tf"
+	})
+
+	if err := parser.LoadModule(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, resource := range parser.ResourcesOfType("test_resource_a") {
+		got = append(got, resource.Name)
+	}
+
+	if diff := cmp.Diff([]string{"main"}, got); diff != "" {
+		t.Errorf("unexpected resources -want +got:\n%s", diff)
+	}
+}
+
+func TestParser_OverrideFiles(t *testing.T) {
+	t.Parallel()
+
+	parser, err := NewParser(&tfjson.ProviderSchemas{
+		Schemas: map[string]*tfjson.ProviderSchema{
+			"registry.terraform.io/test/test": {
+				ResourceSchemas: map[string]*tfjson.Schema{
+					"test_resource": {
+						Block: &tfjson.SchemaBlock{
+							Attributes: map[string]*tfjson.SchemaAttribute{
+								"foo": {AttributeType: cty.String},
+								"bar": {AttributeType: cty.String},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.tf": `
+terraform {
+	required_providers {
+		test = {
+			source = "test/test"
+		}
+	}
+}
+
+resource "test_resource" "test" {
+	provider = test.eu
+	foo      = "base"
+	bar      = "base"
+	count    = 1
+}
+`,
+		"override.tf": `
+resource "test_resource" "test" {
+	foo = "override"
+}
+`,
+		"b_override.tf": `
+resource "test_resource" "test" {
+	foo   = "b_override"
+	count = 2
+}
+`,
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := parser.LoadModule(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	resource := parser.module.ManagedResources["test_resource.test"]
+
+	attrs, err := parser.ResourceAttributes(resource, []string{"foo", "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// override files are merged in lexical order, so override.tf is applied last
+	wantAttrs := map[string]interface{}{"foo": "override", "bar": "base"}
+	if diff := cmp.Diff(wantAttrs, attrs); diff != "" {
+		t.Errorf("unexpected attributes -want +got:\n%s", diff)
+	}
+
+	meta, err := parser.ResourceMetaArguments(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(map[string]string{"count": "2", "provider": "test.eu"}, meta); diff != "" {
+		t.Errorf("unexpected meta-arguments -want +got:\n%s", diff)
+	}
+
+	// the override blocks do not set the provider, so that of main.tf applies
+	provider, err := parser.ResourceProvider(resource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(tfconfig.ProviderRef{Name: "test", Alias: "eu"}, provider); diff != "" {
+		t.Errorf("unexpected provider -want +got:\n%s", diff)
+	}
+
+	rng, diags := parser.ResourceRange(resource)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	if got, want := filepath.Base(rng.Filename), "main.tf"; got != want {
+		t.Errorf("unexpected resource file %s, want %s", got, want)
+	}
+
+	ranges, err := parser.AttributeRanges(resource, []string{"foo", "bar"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := filepath.Base(ranges["foo"].Filename), "override.tf"; got != want {
+		t.Errorf("unexpected file of foo %s, want %s", got, want)
+	}
+
+	if got, want := filepath.Base(ranges["bar"].Filename), "main.tf"; got != want {
+		t.Errorf("unexpected file of bar %s, want %s", got, want)
+	}
+}
+
+func TestParser_ResourceRange(t *testing.T) {
+	t.Parallel()
+
+	parser, err := NewParser(&tfjson.ProviderSchemas{
+		Schemas: map[string]*tfjson.ProviderSchema{
+			"registry.terraform.io/test/test": {
+				ResourceSchemas: map[string]*tfjson.Schema{
+					"test_resource": {
+						Block: &tfjson.SchemaBlock{
+							Attributes: map[string]*tfjson.SchemaAttribute{
+								"foo": {
+									AttributeType: cty.String,
+								},
+								"bar": {
+									AttributeType: cty.String,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	config := `
+resource "test_resource" "test" {
+	foo = "foo"
+
+	bar = <<-EOT
+		bar
+	EOT
+}
+
+terraform {
+	required_providers {
+		test = {
+			source = "test/test"
+		}
+	}
+}
+`
+
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parser.LoadModule(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	resource := parser.module.ManagedResources["test_resource.test"]
+
+	rng, diags := parser.ResourceRange(resource)
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	if rng.Start.Line != 2 || rng.End.Line != 8 {
+		t.Errorf("unexpected resource range L%d-L%d, want L2-L8", rng.Start.Line, rng.End.Line)
+	}
+
+	ranges, err := parser.AttributeRanges(resource, []string{"foo", "bar", "baz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := map[string][2]int{}
+	for attr, rng := range ranges {
+		lines[attr] = [2]int{rng.Start.Line, rng.End.Line}
+	}
+
+	want := map[string][2]int{
+		"foo": {3, 3},
+		"bar": {5, 7},
+	}
+
+	if diff := cmp.Diff(lines, want); diff != "" {
+		t.Errorf("unexpected attribute lines -want +got:\n%s", diff)
+	}
+}
+
+func TestParser_ResourceMetaArguments(t *testing.T) {
+	t.Parallel()
+
+	parser, err := NewParser(&tfjson.ProviderSchemas{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	config := `
+resource "test_resource" "test" {
+	count      = var.enabled ? 1 : 0
+	provider   = test.eu
+	depends_on = [test_resource.other]
+
+	foo = "bar"
+}
+`
+
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parser.LoadModule(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := parser.ResourceMetaArguments(parser.module.ManagedResources["test_resource.test"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"count":      "var.enabled ? 1 : 0",
+		"provider":   "test.eu",
+		"depends_on": "[test_resource.other]",
+	}
+
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("unexpected meta-arguments -want +got:\n%s", diff)
+	}
+}
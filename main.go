@@ -2,36 +2,107 @@ package main
 
 import (
 	"context"
+	"os"
 	"strconv"
 
 	"github.com/observeinc/terraform-resource-markdown-table-action/internal/action"
 	"github.com/sethvargo/go-githubactions"
 )
 
-const defaultHeaderLevel = 2
-
 func main() {
 	inputs := action.Inputs{
 		WorkingDirectory: githubactions.GetInput("working_directory"),
-		OutputFile:       githubactions.GetInput("output_file"),
+		Concurrency:      intFromInput("concurrency", githubactions.GetInput("concurrency")),
+		InventoryFile:    githubactions.GetInput("inventory_file"),
+		OutputFile:       outputFileFromInput(githubactions.GetInput("output_file")),
 		ResourceTypes:    action.ResourcesInput(githubactions.GetInput("resources")),
-		HeaderLevel:      headerLevelFromInput(githubactions.GetInput("resource_header_level")),
+		HeaderLevel:      intFromInput("resource_header_level", githubactions.GetInput("resource_header_level")),
+		Mode:             githubactions.GetInput("mode"),
+		RequireFence:     optionalBoolFromInput("require_fence", githubactions.GetInput("require_fence")),
+		FenceStyle:       githubactions.GetInput("fence_style"),
+		FenceBegin:       githubactions.GetInput("fence_begin"),
+		FenceEnd:         githubactions.GetInput("fence_end"),
+		AnchorHeading:    githubactions.GetInput("anchor_heading"),
+		DryRun:           boolFromInput("dry_run", githubactions.GetInput("dry_run")),
+		Summary:          boolFromInput("summary", githubactions.GetInput("summary")),
+		SummaryCollapsed: boolFromInput("summary_collapsed", githubactions.GetInput("summary_collapsed")),
+		LinkMode:         githubactions.GetInput("link_mode"),
+		LinkRef:          githubactions.GetInput("link_ref"),
+		Include:          githubactions.GetInput("include"),
+		Exclude:          githubactions.GetInput("exclude"),
+		JSONFile:         githubactions.GetInput("json_file"),
+		CompareRef:       githubactions.GetInput("compare_ref"),
 	}
 
-	if err := action.Run(context.Background(), inputs); err != nil {
+	if err := action.Run(context.Background(), inputs, reporter{githubactions.New()}); err != nil {
 		githubactions.Fatalf("%v", err)
 	}
 }
 
-func headerLevelFromInput(input string) int {
+func intFromInput(name string, input string) int {
 	if input == "" {
-		return defaultHeaderLevel
+		return 0
 	}
 
 	i, err := strconv.Atoi(input)
 	if err != nil {
-		githubactions.Fatalf("failed to parse resource_header_level: %v", err)
+		githubactions.Fatalf("failed to parse %s: %v", name, err)
 	}
 
 	return i
 }
+
+func boolFromInput(name string, input string) bool {
+	if input == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(input)
+	if err != nil {
+		githubactions.Fatalf("failed to parse %s: %v", name, err)
+	}
+
+	return b
+}
+
+// outputFileFromInput writes no file if the input is empty. The default,
+// README.md, may be replaced by the configuration files.
+func outputFileFromInput(input string) string {
+	switch input {
+	case "":
+		return action.NoOutputFile
+	case action.DefaultOutputFile:
+		return ""
+	default:
+		return input
+	}
+}
+
+// optionalBoolFromInput returns nil if the input is not set.
+func optionalBoolFromInput(name string, input string) *bool {
+	if input == "" {
+		return nil
+	}
+
+	b := boolFromInput(name, input)
+
+	return &b
+}
+
+// reporter reports via workflow commands, skipping outputs and the job
+// summary when not running in GitHub Actions.
+type reporter struct {
+	*githubactions.Action
+}
+
+func (r reporter) SetOutput(name string, value string) {
+	if os.Getenv("GITHUB_OUTPUT") != "" {
+		r.Action.SetOutput(name, value)
+	}
+}
+
+func (r reporter) AddStepSummary(markdown string) {
+	if os.Getenv("GITHUB_STEP_SUMMARY") != "" {
+		r.Action.AddStepSummary(markdown)
+	}
+}